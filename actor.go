@@ -0,0 +1,84 @@
+package audit
+
+import "fmt"
+
+// NewActor starts an Actor that can be extended with the With* and Via
+// builders:
+//
+//	actor := audit.NewActor("support-7", audit.ActorTypeUser).
+//		WithEmail("support@example.com").
+//		ActingFor(audit.NewActor("customer-1", audit.ActorTypeUser)).
+//		Via("admin-console", audit.ActorTypeService, "impersonation")
+func NewActor(id string, actorType ActorType) Actor {
+	return Actor{ID: id, Type: actorType}
+}
+
+func (a Actor) WithEmail(email string) Actor {
+	a.Email = email
+	return a
+}
+
+func (a Actor) WithTenant(tenantID string) Actor {
+	a.TenantID = tenantID
+	return a
+}
+
+// ActingFor records the principal on whose behalf the actor performed the
+// action.
+func (a Actor) ActingFor(principal Actor) Actor {
+	a.OnBehalfOf = &principal
+	return a
+}
+
+// Via appends a link to the delegation chain. The chain is ordered from the
+// principal towards the actor: the first link received authority from the
+// principal and the last link handed it to the actor.
+func (a Actor) Via(id string, actorType ActorType, reason string) Actor {
+	return a.ViaLink(DelegationLink{ID: id, Type: actorType, Reason: reason})
+}
+
+func (a Actor) ViaLink(link DelegationLink) Actor {
+	a.Delegation = append(append([]DelegationLink(nil), a.Delegation...), link)
+	return a
+}
+
+// Validate checks the delegation fields. Actors without a principal or
+// delegation chain are always valid.
+func (a Actor) Validate() error {
+	if a.OnBehalfOf == nil && len(a.Delegation) == 0 {
+		return nil
+	}
+	if a.ID == "" {
+		return fmt.Errorf("%w: delegated actor has no id", ErrInvalidDelegation)
+	}
+
+	seen := map[string]bool{a.ID: true}
+
+	if p := a.OnBehalfOf; p != nil {
+		if p.ID == "" {
+			return fmt.Errorf("%w: principal has no id", ErrInvalidDelegation)
+		}
+		if p.OnBehalfOf != nil || len(p.Delegation) > 0 {
+			return fmt.Errorf("%w: principal %s is itself delegated", ErrInvalidDelegation, p.ID)
+		}
+		if p.ID == a.ID {
+			return fmt.Errorf("%w: actor %s acts on behalf of itself", ErrInvalidDelegation, a.ID)
+		}
+		seen[p.ID] = true
+	}
+
+	for i, link := range a.Delegation {
+		if link.ID == "" {
+			return fmt.Errorf("%w: link %d has no id", ErrInvalidDelegation, i)
+		}
+		if link.Type == "" {
+			return fmt.Errorf("%w: link %s has no type", ErrInvalidDelegation, link.ID)
+		}
+		if seen[link.ID] {
+			return fmt.Errorf("%w: %s appears more than once in the chain", ErrInvalidDelegation, link.ID)
+		}
+		seen[link.ID] = true
+	}
+
+	return nil
+}
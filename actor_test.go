@@ -0,0 +1,88 @@
+package audit
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActorBuilder(t *testing.T) {
+	base := NewActor("support-7", ActorTypeUser).WithEmail("support@example.com").WithTenant("tenant-1")
+	actor := base.
+		ActingFor(NewActor("customer-1", ActorTypeUser)).
+		Via("admin-console", ActorTypeService, "impersonation")
+
+	assert.Equal(t, Actor{
+		ID:         "support-7",
+		Email:      "support@example.com",
+		Type:       ActorTypeUser,
+		TenantID:   "tenant-1",
+		OnBehalfOf: &Actor{ID: "customer-1", Type: ActorTypeUser},
+		Delegation: []DelegationLink{{ID: "admin-console", Type: ActorTypeService, Reason: "impersonation"}},
+	}, actor)
+	assert.NoError(t, actor.Validate())
+
+	assert.Nil(t, base.OnBehalfOf, "builders must not modify the receiver")
+	assert.Empty(t, base.Delegation)
+}
+
+func TestActorBuilder_ViaDoesNotAlias(t *testing.T) {
+	base := NewActor("svc-1", ActorTypeService).Via("a", ActorTypeService, "")
+	first := base.Via("b", ActorTypeService, "")
+	second := base.Via("c", ActorTypeService, "")
+
+	assert.Equal(t, "b", first.Delegation[1].ID)
+	assert.Equal(t, "c", second.Delegation[1].ID)
+}
+
+func TestActor_Validate(t *testing.T) {
+	tests := []struct {
+		name  string
+		actor Actor
+		valid bool
+	}{
+		{"plain actor", Actor{Type: ActorTypeSystem}, true},
+		{"principal", NewActor("a", ActorTypeUser).ActingFor(NewActor("b", ActorTypeUser)), true},
+		{"missing actor id", Actor{OnBehalfOf: &Actor{ID: "b"}}, false},
+		{"missing principal id", NewActor("a", ActorTypeUser).ActingFor(Actor{}), false},
+		{"self delegation", NewActor("a", ActorTypeUser).ActingFor(NewActor("a", ActorTypeUser)), false},
+		{"nested principal", NewActor("a", ActorTypeUser).ActingFor(NewActor("b", ActorTypeUser).ActingFor(NewActor("c", ActorTypeUser))), false},
+		{"link without id", NewActor("a", ActorTypeUser).Via("", ActorTypeService, ""), false},
+		{"link without type", NewActor("a", ActorTypeUser).Via("b", "", ""), false},
+		{"cycle", NewActor("a", ActorTypeUser).Via("b", ActorTypeService, "").Via("a", ActorTypeUser, ""), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.actor.Validate()
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidDelegation)
+			}
+		})
+	}
+}
+
+func TestActor_SerializationBackwardCompatible(t *testing.T) {
+	data, err := json.Marshal(NewActor("user-1", ActorTypeUser))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"user-1","type":"user"}`, string(data))
+
+	var actor Actor
+	require.NoError(t, json.Unmarshal([]byte(`{"id":"user-1","email":"u@example.com","type":"user"}`), &actor))
+	assert.Equal(t, NewActor("user-1", ActorTypeUser).WithEmail("u@example.com"), actor)
+}
+
+func TestClient_Emit_RejectsInvalidDelegation(t *testing.T) {
+	mock := &mockProducer{}
+	c := &client{config: &Config{}, producer: mock, topics: []string{"events"}, logger: testLogger()}
+
+	actor := NewActor("a", ActorTypeUser).Via("a", ActorTypeUser, "")
+	err := c.Emit(Event{TeamID: "team-1", Event: EventInfo{Type: "test.event"}, Actor: &actor})
+
+	assert.ErrorIs(t, err, ErrInvalidDelegation)
+	assert.Empty(t, mock.producedMessages)
+}
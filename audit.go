@@ -1,13 +1,16 @@
 package audit
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"sync"
 	"time"
 
 	"github.com/NeuralTrust/audit-sdk-go/kafka"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Client interface {
@@ -15,13 +18,34 @@ type Client interface {
 	Close() error
 }
 
+// ContextEmitter is implemented by clients that fill events from the Scope
+// and trace carried by ctx.
+type ContextEmitter interface {
+	EmitContext(ctx context.Context, event Event) error
+}
+
+var _ ContextEmitter = (*client)(nil)
+
 type client struct {
 	config   *Config
 	producer Producer
 	topics   []string
+	router   *router
 	closed   bool
 	mu       sync.RWMutex
 	logger   *slog.Logger
+	chain    *hashChain
+	signer   Signer
+
+	checkpoints *checkpointer
+	tracer      trace.Tracer
+	source      *Source
+	deliveries  *deliveryWindow
+	spans       *produceSpans
+	deadLetters *deadLetterWriter
+
+	signatureOnce sync.Once
+	signatureSize int
 }
 
 func New(cfg *Config) (Client, error) {
@@ -43,26 +67,35 @@ func New(cfg *Config) (Client, error) {
 		TopicAutoCreate:  cfg.TopicAutoCreate,
 		TopicNumParts:    cfg.TopicNumParts,
 		TopicReplication: cfg.TopicReplication,
-	}
+		StatsInterval:    cfg.StatsInterval,
+		OnStats:          cfg.OnStats,
+		QueueMaxMessages: cfg.QueueMaxMessages,
+		QueueFullPolicy:  cfg.QueueFullPolicy,
+		BlockTimeout:     cfg.BlockTimeout,
+		OverflowBuffer:   cfg.OverflowBuffer,
+		SpoolDir:         cfg.SpoolDir,
+		SpoolMaxBytes:    cfg.SpoolMaxBytes,
 
-	if cfg.TLS != nil {
-		kafkaCfg.TLS = &kafka.TLSConfig{
-			Enable:             cfg.TLS.Enable,
-			CertFile:           cfg.TLS.CertFile,
-			KeyFile:            cfg.TLS.KeyFile,
-			CAFile:             cfg.TLS.CAFile,
-			InsecureSkipVerify: cfg.TLS.InsecureSkipVerify,
-		}
+		Idempotent:         cfg.Idempotent,
+		MaxInFlight:        cfg.MaxInFlight,
+		TransactionalID:    cfg.TransactionalID,
+		TransactionTimeout: cfg.TransactionTimeout,
+		BatchSize:          cfg.BatchSize,
+		BatchTimeout:       cfg.BatchTimeout,
 	}
 
-	if cfg.SASL != nil {
-		kafkaCfg.SASL = &kafka.SASLConfig{
-			Enable:    cfg.SASL.Enable,
-			Mechanism: cfg.SASL.Mechanism,
-			Username:  cfg.SASL.Username,
-			Password:  cfg.SASL.Password,
-		}
+	c := &client{
+		config:     cfg,
+		logger:     cfg.Logger,
+		signer:     cfg.Signer,
+		deliveries: newDeliveryWindow(cfg.Health.Window),
+	}
+	if c.logger == nil {
+		c.logger = newLogger(cfg.LogLevel)
 	}
+	kafkaCfg.OnDelivery = c.handleDelivery
+	kafkaCfg.OnDrop = c.handleDrop
+	kafkaCfg.TLS, kafkaCfg.SASL = cfg.kafkaSecurity()
 
 	producer, err := kafka.NewProducer(kafkaCfg)
 	if err != nil {
@@ -70,23 +103,60 @@ func New(cfg *Config) (Client, error) {
 	}
 
 	topics := []string{cfg.AuditEventsTopic, cfg.AuditLogsIngestTopic}
+	if len(cfg.Routes) > 0 {
+		c.router = newRouter(topics, cfg.Routes)
+	}
 
 	if cfg.TopicAutoCreate {
-		if err := producer.EnsureTopics(topics); err != nil {
+		ensure := append(append([]string(nil), topics...), c.router.static()...)
+		ensure = append(ensure, cfg.auxiliaryTopics()...)
+		if err := producer.EnsureTopics(ensure); err != nil {
 			_ = producer.Close()
 			return nil, err
 		}
 	}
 
-	return &client{
-		config:   cfg,
-		producer: producer,
-		topics:   topics,
-		logger:   newLogger(cfg.LogLevel),
-	}, nil
+	c.producer = producer
+	c.topics = topics
+
+	if cfg.HashChain != nil && cfg.HashChain.Enable {
+		c.chain = newHashChain(cfg.HashChain)
+	}
+
+	if cfg.Source != nil && cfg.Source.Enable {
+		c.source = newSource(cfg.Source)
+	}
+
+	if cfg.Tracing != nil && cfg.Tracing.Enable {
+		c.tracer = newTracer(cfg.Tracing)
+		c.spans = newProduceSpans()
+	}
+
+	if cfg.DeadLetter != nil && cfg.DeadLetter.Enable {
+		c.deadLetters = newDeadLetterWriter(producer, cfg.DeadLetter.Topic, c.logger, deadLetterBuffer)
+	}
+
+	if cfg.Checkpoint != nil && cfg.Checkpoint.Enable {
+		c.checkpoints = newCheckpointer(cfg.Checkpoint, producer, c.logger)
+		c.checkpoints.start()
+	}
+
+	return c, nil
 }
 
 func (c *client) Emit(event Event) error {
+	return c.EmitContext(context.Background(), event)
+}
+
+func (c *client) EmitContext(ctx context.Context, event Event) error {
+	if c.config.SyncPublish {
+		_, err := c.Publish(ctx, event)
+		return err
+	}
+	return c.emitContext(ctx, event)
+}
+
+func (c *client) emitContext(ctx context.Context, event Event) error {
 	c.mu.RLock()
 	if c.closed {
 		c.mu.RUnlock()
@@ -94,14 +164,94 @@ func (c *client) Emit(event Event) error {
 	}
 	c.mu.RUnlock()
 
-	if err := c.validateEvent(&event); err != nil {
+	if scope := ScopeFromContext(ctx); scope != nil {
+		scope.Apply(&event)
+	}
+
+	captureTrace(ctx, &event)
+
+	if c.tracer == nil {
+		return c.intercept(ctx, &event)
+	}
+
+	spanCtx, span := c.startSpan(ctx, &event)
+	err := c.intercept(spanCtx, &event)
+	c.endSpan(span, &event, err)
+
+	if err == nil && c.config.Tracing.SpanEvents {
+		addSpanEvent(ctx, &event)
+	}
+	return err
+}
+
+func (c *client) emit(ctx context.Context, event *Event) error {
+	if err := c.validateEvent(event); err != nil {
+		c.observer().EventRejected(event, err)
+		c.deadLetterEvent(event, DeadLetterValidation, err)
+		return err
+	}
+
+	if err := c.normalizeTargets(event); err != nil {
+		c.observer().EventRejected(event, err)
+		c.deadLetterEvent(event, DeadLetterValidation, err)
 		return err
 	}
 
-	c.enrichEvent(&event)
+	c.enrichEvent(event)
+
+	if err := c.enforceSizeLimit(event); err != nil {
+		if errors.Is(err, ErrEventTooLarge) {
+			c.observer().EventRejected(event, err)
+			c.deadLetterEvent(event, DeadLetterTooLarge, err)
+		} else {
+			c.deadLetterEvent(event, DeadLetterEncode, err)
+		}
+		return err
+	}
+
+	emit := func() error {
+		return c.publish(ctx, event)
+	}
+
+	if c.chain != nil {
+		publish := emit
+		emit = func() error {
+			return c.chain.seal(event, publish)
+		}
+	}
+
+	if c.checkpoints != nil {
+		seal := emit
+		emit = func() error {
+			return c.checkpoints.record(event, seal)
+		}
+	}
+
+	return emit()
+}
+
+func (c *client) publish(ctx context.Context, event *Event) error {
+	if c.signer != nil {
+		if err := SignEvent(event, c.signer); err != nil {
+			c.handleError(*event, err, "failed to sign audit event")
+			c.observer().EncodeFailed(event, err)
+			c.deadLetterEvent(event, DeadLetterEncode, err)
+			return err
+		}
+	}
 
 	data, err := json.Marshal(event)
 	if err != nil {
+		c.handleError(*event, err, "failed to encode audit event")
+		c.observer().EncodeFailed(event, err)
+		c.deadLetterEvent(event, DeadLetterEncode, err)
+		return err
+	}
+
+	if limit := c.config.MaxEventSize; limit > 0 && len(data) > limit {
+		err := &EventTooLargeError{EventID: event.ID, Size: len(data), Limit: limit}
+		c.observer().EventRejected(event, err)
+		c.deadLetterEvent(event, DeadLetterTooLarge, err)
 		return err
 	}
 
@@ -114,10 +264,64 @@ func (c *client) Emit(event Event) error {
 		slog.Any("payload", string(data)),
 	)
 
-	c.producer.ProduceAsync(c.topics, []byte(event.TeamID), data)
+	topics := c.eventTopics(event)
+	if result, ok := ctx.Value(publishKey{}).(*PublishResult); ok {
+		return c.publishSync(ctx, event, topics, data, result)
+	}
+
+	c.produceTraced(ctx, event, topics, data)
+	c.observer().EventEmitted(event)
+	c.queueChanged()
 	return nil
 }
 
+func (c *client) handleDelivery(report kafka.DeliveryReport) {
+	if report.Enqueued {
+		c.observer().Delivered(report.Topic, report.Latency, report.Err)
+	} else {
+		c.observer().ProduceFailed(report.Topic, report.Err)
+	}
+	c.queueChanged()
+	c.deliveries.record(time.Now(), report.Err != nil)
+	if c.checkpointReport(report.Topic, report.Key, report.Err) {
+		return
+	}
+	c.traceDelivery(report.Opaque, report.Topic, report.Latency, report.Err)
+
+	if report.Err == nil {
+		return
+	}
+
+	var event Event
+	if err := json.Unmarshal(report.Value, &event); err != nil {
+		c.logger.Warn("failed to decode undelivered audit message",
+			slog.String("topic", report.Topic),
+			slog.String("error", err.Error()),
+		)
+	}
+
+	msg := "failed to deliver audit event"
+	if !report.Enqueued {
+		msg = "failed to produce audit event"
+	}
+	c.handleError(event, report.Err, msg, slog.String("topic", report.Topic))
+	c.deadLetterReport(event, report)
+}
+
+func (c *client) handleError(event Event, err error, msg string, attrs ...slog.Attr) {
+	attrs = append([]slog.Attr{
+		slog.String("event_id", event.ID),
+		slog.String("team_id", event.TeamID),
+		slog.String("event_type", event.Event.Type),
+		slog.String("error", err.Error()),
+	}, attrs...)
+	c.logger.LogAttrs(context.Background(), slog.LevelError, msg, attrs...)
+
+	if c.config.OnError != nil {
+		c.config.OnError(event, err)
+	}
+}
+
 func (c *client) Close() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -127,7 +331,16 @@ func (c *client) Close() error {
 	}
 
 	c.closed = true
-	return c.producer.Close()
+
+	if c.checkpoints != nil {
+		c.checkpoints.close()
+	}
+
+	c.deadLetters.close()
+
+	err := c.producer.Close()
+	c.closeSpans()
+	return err
 }
 
 func (c *client) validateEvent(event *Event) error {
@@ -137,6 +350,11 @@ func (c *client) validateEvent(event *Event) error {
 	if event.Event.Type == "" {
 		return ErrEmptyEventType
 	}
+	if event.Actor != nil {
+		if err := event.Actor.Validate(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -150,5 +368,9 @@ func (c *client) enrichEvent(event *Event) {
 	if event.Timestamp.IsZero() {
 		event.Timestamp = Timestamp{Time: time.Now().UTC()}
 	}
-}
 
+	if event.Source == nil && c.source != nil {
+		source := *c.source
+		event.Source = &source
+	}
+}
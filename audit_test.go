@@ -1,11 +1,15 @@
 package audit
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"log/slog"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/NeuralTrust/audit-sdk-go/kafka"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -44,6 +48,34 @@ func (m *mockProducer) Close() error {
 	return nil
 }
 
+// newTestClient builds a client around producer the way New does for cfg,
+// without connecting to Kafka. Its topics default to "events". Dead letters
+// are produced directly rather than through a writer.
+func newTestClient(producer Producer, cfg *Config, topics ...string) *client {
+	if len(topics) == 0 {
+		topics = []string{"events"}
+	}
+	c := &client{
+		config:     cfg,
+		producer:   producer,
+		topics:     topics,
+		logger:     testLogger(),
+		signer:     cfg.Signer,
+		deliveries: newDeliveryWindow(time.Minute),
+	}
+	if cfg.HashChain != nil && cfg.HashChain.Enable {
+		c.chain = newHashChain(cfg.HashChain)
+	}
+	if cfg.Tracing != nil && cfg.Tracing.Enable {
+		c.tracer = newTracer(cfg.Tracing)
+		c.spans = newProduceSpans()
+	}
+	if cfg.Checkpoint != nil && cfg.Checkpoint.Enable {
+		c.checkpoints = newCheckpointer(cfg.Checkpoint, producer, c.logger)
+	}
+	return c
+}
+
 func TestClient_Emit_Success(t *testing.T) {
 	mock := &mockProducer{}
 	c := &client{
@@ -272,3 +304,62 @@ func TestEnrichEvent_PreservesExistingValues(t *testing.T) {
 }
 
 
+
+func TestClient_HandleDelivery_RoutesFailures(t *testing.T) {
+	var logs bytes.Buffer
+	var failed []Event
+	var errs []error
+
+	c := &client{
+		config: &Config{OnError: func(event Event, err error) {
+			failed = append(failed, event)
+			errs = append(errs, err)
+		}},
+		logger: slog.New(slog.NewJSONHandler(&logs, nil)),
+	}
+
+	data, err := json.Marshal(Event{ID: "evt-1", TeamID: "team-1", Event: EventInfo{Type: "test.event"}})
+	require.NoError(t, err)
+
+	c.handleDelivery(kafka.DeliveryReport{Topic: "events", Value: data, Enqueued: true})
+	assert.Empty(t, failed)
+
+	deliveryErr := errors.New("broker unavailable")
+	c.handleDelivery(kafka.DeliveryReport{Topic: "events", Key: []byte("team-1"), Value: data, Err: deliveryErr, Enqueued: true})
+
+	require.Len(t, failed, 1)
+	assert.Equal(t, "evt-1", failed[0].ID)
+	assert.Equal(t, deliveryErr, errs[0])
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &entry))
+	assert.Equal(t, "failed to deliver audit event", entry["msg"])
+	assert.Equal(t, "evt-1", entry["event_id"])
+	assert.Equal(t, "events", entry["topic"])
+	assert.Equal(t, "broker unavailable", entry["error"])
+}
+
+func TestClient_Emit_EncodeFailureCallsOnError(t *testing.T) {
+	var errs []error
+	mock := &mockProducer{}
+	c := &client{
+		config: &Config{
+			MaxEventSize: -1,
+			OnError:      func(_ Event, err error) { errs = append(errs, err) },
+		},
+		producer: mock,
+		topics:   []string{"events"},
+		logger:   testLogger(),
+	}
+
+	err := c.Emit(Event{
+		TeamID:   "team-1",
+		Event:    EventInfo{Type: "test.event"},
+		Metadata: &Metadata{"bad": make(chan int)},
+	})
+
+	assert.Error(t, err)
+	require.Len(t, errs, 1)
+	assert.Equal(t, err, errs[0])
+	assert.Empty(t, mock.producedMessages)
+}
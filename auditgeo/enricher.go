@@ -0,0 +1,404 @@
+package auditgeo
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	audit "github.com/NeuralTrust/audit-sdk-go"
+	"github.com/oschwald/maxminddb-golang"
+)
+
+const (
+	DefaultCacheSize      = 10000
+	DefaultReloadInterval = 1 * time.Minute
+	DefaultLanguage       = "en"
+)
+
+// Config lists the MMDB files to consult. City, Country and ASN databases
+// can be combined; fields found in earlier databases win.
+type Config struct {
+	Databases      []string
+	CacheSize      int
+	ReloadInterval time.Duration
+	Language       string
+	OnError        func(path string, err error)
+}
+
+type Enricher struct {
+	config *Config
+
+	mu        sync.RWMutex
+	reloadMu  sync.Mutex
+	databases []*database
+
+	cacheMu sync.Mutex
+	cache   map[string]*list.Element
+	lru     *list.List
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+type database struct {
+	path    string
+	modTime time.Time
+	reader  *maxminddb.Reader
+}
+
+type cacheEntry struct {
+	ip  string
+	geo *audit.Geo
+}
+
+type record struct {
+	Country struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	Subdivisions []struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Location struct {
+		Latitude  float64 `maxminddb:"latitude"`
+		Longitude float64 `maxminddb:"longitude"`
+	} `maxminddb:"location"`
+	ASN   uint   `maxminddb:"autonomous_system_number"`
+	ASOrg string `maxminddb:"autonomous_system_organization"`
+}
+
+func New(cfg *Config) (*Enricher, error) {
+	if cfg == nil || len(cfg.Databases) == 0 {
+		return nil, errors.New("auditgeo: no databases configured")
+	}
+	if cfg.CacheSize == 0 {
+		cfg.CacheSize = DefaultCacheSize
+	}
+	if cfg.ReloadInterval == 0 {
+		cfg.ReloadInterval = DefaultReloadInterval
+	}
+	if cfg.Language == "" {
+		cfg.Language = DefaultLanguage
+	}
+
+	e := &Enricher{
+		config: cfg,
+		cache:  make(map[string]*list.Element),
+		lru:    list.New(),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	for _, path := range cfg.Databases {
+		db, err := openDatabase(path)
+		if err != nil {
+			e.closeDatabases()
+			return nil, err
+		}
+		e.databases = append(e.databases, db)
+	}
+
+	if cfg.ReloadInterval > 0 {
+		go e.watch()
+	} else {
+		close(e.done)
+	}
+
+	return e, nil
+}
+
+func openDatabase(path string) (*database, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("auditgeo: %w", err)
+	}
+
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("auditgeo: open %s: %w", path, err)
+	}
+
+	return &database{path: path, modTime: info.ModTime(), reader: reader}, nil
+}
+
+// Interceptor returns an audit.Interceptor that normalizes
+// Context.IPAddress and fills Context.Geo from the databases.
+func (e *Enricher) Interceptor() audit.Interceptor {
+	return func(ctx context.Context, event *audit.Event, next audit.Next) error {
+		e.Enrich(event)
+		return next(ctx, event)
+	}
+}
+
+func (e *Enricher) Enrich(event *audit.Event) {
+	if event.Context == nil || event.Context.IPAddress == "" {
+		return
+	}
+
+	auditCtx := *event.Context
+	event.Context = &auditCtx
+
+	ip, ok := NormalizeIP(auditCtx.IPAddress)
+	if !ok {
+		return
+	}
+	auditCtx.IPAddress = ip
+
+	if auditCtx.Geo == nil {
+		auditCtx.Geo = e.Lookup(ip)
+	}
+}
+
+// Lookup returns the location of ip, or nil when the address is invalid or
+// not found in any database.
+func (e *Enricher) Lookup(ip string) *audit.Geo {
+	normalized, ok := NormalizeIP(ip)
+	if !ok {
+		return nil
+	}
+
+	if geo, ok := e.cached(normalized); ok {
+		return copyGeo(geo)
+	}
+
+	geo := e.lookup(net.ParseIP(normalized))
+	e.store(normalized, geo)
+	return copyGeo(geo)
+}
+
+func (e *Enricher) lookup(ip net.IP) *audit.Geo {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	var geo audit.Geo
+	found := false
+
+	for _, db := range e.databases {
+		var rec record
+		if err := db.reader.Lookup(ip, &rec); err != nil {
+			e.reportError(db.path, err)
+			continue
+		}
+		found = merge(&geo, &rec, e.config.Language) || found
+	}
+
+	if !found {
+		return nil
+	}
+	return &geo
+}
+
+func merge(geo *audit.Geo, rec *record, lang string) bool {
+	found := false
+	set := func(dst *string, value string) {
+		if value == "" {
+			return
+		}
+		found = true
+		if *dst == "" {
+			*dst = value
+		}
+	}
+
+	set(&geo.CountryCode, rec.Country.ISOCode)
+	set(&geo.Country, rec.Country.Names[lang])
+	if len(rec.Subdivisions) > 0 {
+		set(&geo.Region, rec.Subdivisions[0].Names[lang])
+	}
+	set(&geo.City, rec.City.Names[lang])
+	set(&geo.ASOrg, rec.ASOrg)
+
+	if rec.Location.Latitude != 0 || rec.Location.Longitude != 0 {
+		found = true
+		if geo.Latitude == 0 && geo.Longitude == 0 {
+			geo.Latitude = rec.Location.Latitude
+			geo.Longitude = rec.Location.Longitude
+		}
+	}
+	if rec.ASN != 0 {
+		found = true
+		if geo.ASN == 0 {
+			geo.ASN = rec.ASN
+		}
+	}
+
+	return found
+}
+
+func (e *Enricher) cached(ip string) (*audit.Geo, bool) {
+	e.cacheMu.Lock()
+	defer e.cacheMu.Unlock()
+
+	elem, ok := e.cache[ip]
+	if !ok {
+		return nil, false
+	}
+	e.lru.MoveToFront(elem)
+	return elem.Value.(*cacheEntry).geo, true
+}
+
+func (e *Enricher) store(ip string, geo *audit.Geo) {
+	if e.config.CacheSize < 0 {
+		return
+	}
+
+	e.cacheMu.Lock()
+	defer e.cacheMu.Unlock()
+
+	if elem, ok := e.cache[ip]; ok {
+		elem.Value.(*cacheEntry).geo = geo
+		e.lru.MoveToFront(elem)
+		return
+	}
+
+	e.cache[ip] = e.lru.PushFront(&cacheEntry{ip: ip, geo: geo})
+	for e.lru.Len() > e.config.CacheSize {
+		oldest := e.lru.Back()
+		e.lru.Remove(oldest)
+		delete(e.cache, oldest.Value.(*cacheEntry).ip)
+	}
+}
+
+func (e *Enricher) purge() {
+	e.cacheMu.Lock()
+	defer e.cacheMu.Unlock()
+
+	e.cache = make(map[string]*list.Element)
+	e.lru.Init()
+}
+
+func (e *Enricher) watch() {
+	defer close(e.done)
+
+	ticker := time.NewTicker(e.config.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			e.Reload()
+		case <-e.stop:
+			return
+		}
+	}
+}
+
+// Reload reopens every database whose file modification time changed since
+// it was loaded. It is called periodically when ReloadInterval is positive.
+func (e *Enricher) Reload() {
+	e.reloadMu.Lock()
+	defer e.reloadMu.Unlock()
+
+	e.mu.RLock()
+	current := append([]*database(nil), e.databases...)
+	e.mu.RUnlock()
+
+	reloaded := false
+	for i, db := range current {
+		info, err := os.Stat(db.path)
+		if err != nil {
+			e.reportError(db.path, err)
+			continue
+		}
+		if info.ModTime().Equal(db.modTime) {
+			continue
+		}
+
+		fresh, err := openDatabase(db.path)
+		if err != nil {
+			e.reportError(db.path, err)
+			continue
+		}
+		current[i] = fresh
+		reloaded = true
+	}
+
+	if !reloaded {
+		return
+	}
+
+	e.mu.Lock()
+	old := e.databases
+	e.databases = current
+	e.mu.Unlock()
+
+	for i, db := range old {
+		if current[i] != db {
+			_ = db.reader.Close()
+		}
+	}
+	e.purge()
+}
+
+func (e *Enricher) Close() error {
+	select {
+	case <-e.stop:
+		return nil
+	default:
+		close(e.stop)
+	}
+	<-e.done
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.closeDatabases()
+}
+
+func (e *Enricher) closeDatabases() error {
+	var errs []error
+	for _, db := range e.databases {
+		errs = append(errs, db.reader.Close())
+	}
+	e.databases = nil
+	return errors.Join(errs...)
+}
+
+func (e *Enricher) reportError(path string, err error) {
+	if e.config.OnError != nil {
+		e.config.OnError(path, err)
+	}
+}
+
+// NormalizeIP validates an IPv4 or IPv6 address and returns its canonical
+// form. Ports ("192.0.2.1:8080", "[2001:db8::1]:443"), brackets and zones
+// are stripped, and IPv4-mapped IPv6 addresses are returned as IPv4.
+func NormalizeIP(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", false
+	}
+
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+	if idx := strings.IndexByte(s, '%'); idx >= 0 {
+		s = s[:idx]
+	}
+
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return "", false
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return v4.String(), true
+	}
+	return ip.String(), true
+}
+
+func copyGeo(geo *audit.Geo) *audit.Geo {
+	if geo == nil {
+		return nil
+	}
+	c := *geo
+	return &c
+}
@@ -0,0 +1,200 @@
+package auditgeo
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	audit "github.com/NeuralTrust/audit-sdk-go"
+	"github.com/maxmind/mmdbwriter"
+	"github.com/maxmind/mmdbwriter/mmdbtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeDatabase(t *testing.T, path, dbType string, records map[string]mmdbtype.Map) {
+	t.Helper()
+
+	tree, err := mmdbwriter.New(mmdbwriter.Options{DatabaseType: dbType, IncludeReservedNetworks: true})
+	require.NoError(t, err)
+
+	for cidr, data := range records {
+		_, network, err := net.ParseCIDR(cidr)
+		require.NoError(t, err)
+		require.NoError(t, tree.Insert(network, data))
+	}
+
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	require.NoError(t, err)
+	_, err = tree.WriteTo(f)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	require.NoError(t, os.Rename(tmp, path))
+}
+
+func cityRecord(code, country, city string) mmdbtype.Map {
+	return mmdbtype.Map{
+		"country": mmdbtype.Map{
+			"iso_code": mmdbtype.String(code),
+			"names":    mmdbtype.Map{"en": mmdbtype.String(country)},
+		},
+		"subdivisions": mmdbtype.Slice{
+			mmdbtype.Map{"names": mmdbtype.Map{"en": mmdbtype.String("Region")}},
+		},
+		"city": mmdbtype.Map{
+			"names": mmdbtype.Map{"en": mmdbtype.String(city)},
+		},
+		"location": mmdbtype.Map{
+			"latitude":  mmdbtype.Float64(52.52),
+			"longitude": mmdbtype.Float64(13.405),
+		},
+	}
+}
+
+func asnRecord(asn uint32, org string) mmdbtype.Map {
+	return mmdbtype.Map{
+		"autonomous_system_number":       mmdbtype.Uint32(asn),
+		"autonomous_system_organization": mmdbtype.String(org),
+	}
+}
+
+func newTestEnricher(t *testing.T, cfg *Config) *Enricher {
+	t.Helper()
+	e, err := New(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = e.Close() })
+	return e
+}
+
+func testDatabases(t *testing.T) (string, string) {
+	dir := t.TempDir()
+	city := filepath.Join(dir, "city.mmdb")
+	asn := filepath.Join(dir, "asn.mmdb")
+
+	writeDatabase(t, city, "GeoLite2-City", map[string]mmdbtype.Map{
+		"192.0.2.0/24":  cityRecord("DE", "Germany", "Berlin"),
+		"2001:db8::/32": cityRecord("FR", "France", "Paris"),
+	})
+	writeDatabase(t, asn, "GeoLite2-ASN", map[string]mmdbtype.Map{
+		"192.0.2.0/24": asnRecord(64500, "Example Networks"),
+	})
+	return city, asn
+}
+
+func TestEnricher_Lookup(t *testing.T) {
+	city, asn := testDatabases(t)
+	e := newTestEnricher(t, &Config{Databases: []string{city, asn}, ReloadInterval: -1})
+
+	assert.Equal(t, &audit.Geo{
+		CountryCode: "DE",
+		Country:     "Germany",
+		Region:      "Region",
+		City:        "Berlin",
+		Latitude:    52.52,
+		Longitude:   13.405,
+		ASN:         64500,
+		ASOrg:       "Example Networks",
+	}, e.Lookup("192.0.2.10:443"))
+
+	v6 := e.Lookup("[2001:db8::1]:8443")
+	require.NotNil(t, v6)
+	assert.Equal(t, "Paris", v6.City)
+	assert.Zero(t, v6.ASN)
+
+	assert.Nil(t, e.Lookup("198.51.100.1"))
+	assert.Nil(t, e.Lookup("not-an-ip"))
+}
+
+func TestEnricher_Interceptor(t *testing.T) {
+	city, _ := testDatabases(t)
+	e := newTestEnricher(t, &Config{Databases: []string{city}, ReloadInterval: -1})
+
+	original := &audit.Context{IPAddress: "::ffff:192.0.2.7", RequestID: "req-1"}
+	event := audit.Event{Context: original}
+
+	var seen *audit.Event
+	err := e.Interceptor()(context.Background(), &event, func(ctx context.Context, event *audit.Event) error {
+		seen = event
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "192.0.2.7", seen.Context.IPAddress)
+	assert.Equal(t, "req-1", seen.Context.RequestID)
+	require.NotNil(t, seen.Context.Geo)
+	assert.Equal(t, "DE", seen.Context.Geo.CountryCode)
+	assert.Nil(t, original.Geo, "caller's context must not be modified")
+}
+
+func TestEnricher_CacheAndReload(t *testing.T) {
+	city, _ := testDatabases(t)
+	e := newTestEnricher(t, &Config{Databases: []string{city}, CacheSize: 1, ReloadInterval: -1})
+
+	assert.Equal(t, "Berlin", e.Lookup("192.0.2.1").City)
+	assert.Nil(t, e.Lookup("198.51.100.1"))
+	assert.Equal(t, 1, e.lru.Len())
+
+	writeDatabase(t, city, "GeoLite2-City", map[string]mmdbtype.Map{
+		"192.0.2.0/24": cityRecord("DE", "Germany", "Hamburg"),
+	})
+	future := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(city, future, future))
+
+	e.Reload()
+	assert.Equal(t, "Hamburg", e.Lookup("192.0.2.1").City)
+}
+
+func TestEnricher_WatchReloads(t *testing.T) {
+	city, _ := testDatabases(t)
+	e := newTestEnricher(t, &Config{Databases: []string{city}, ReloadInterval: 10 * time.Millisecond})
+
+	assert.Equal(t, "Berlin", e.Lookup("192.0.2.1").City)
+
+	writeDatabase(t, city, "GeoLite2-City", map[string]mmdbtype.Map{
+		"192.0.2.0/24": cityRecord("DE", "Germany", "Munich"),
+	})
+	future := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(city, future, future))
+
+	require.Eventually(t, func() bool {
+		geo := e.Lookup("192.0.2.1")
+		return geo != nil && geo.City == "Munich"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestNew_Errors(t *testing.T) {
+	_, err := New(&Config{})
+	assert.Error(t, err)
+
+	_, err = New(&Config{Databases: []string{filepath.Join(t.TempDir(), "missing.mmdb")}})
+	assert.Error(t, err)
+}
+
+func TestNormalizeIP(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"192.0.2.1", "192.0.2.1", true},
+		{" 192.0.2.1:8080 ", "192.0.2.1", true},
+		{"::ffff:192.0.2.1", "192.0.2.1", true},
+		{"2001:DB8:0:0::1", "2001:db8::1", true},
+		{"[2001:db8::1]:443", "2001:db8::1", true},
+		{"[2001:db8::1]", "2001:db8::1", true},
+		{"fe80::1%eth0", "fe80::1", true},
+		{"", "", false},
+		{"example.com", "", false},
+		{"999.0.0.1", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := NormalizeIP(tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+		assert.Equal(t, tt.ok, ok, tt.in)
+	}
+}
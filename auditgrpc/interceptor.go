@@ -0,0 +1,234 @@
+package auditgrpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	audit "github.com/NeuralTrust/audit-sdk-go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	DefaultRequestIDKey = "x-request-id"
+	DefaultSessionKey   = "x-session-id"
+)
+
+type Config struct {
+	Client       audit.Client
+	Methods      map[string]Method
+	TeamID       func(ctx context.Context) string
+	RequestIDKey string
+	SessionKey   string
+	OnError      func(ctx context.Context, fullMethod string, err error)
+}
+
+// Method maps a gRPC method to the event emitted for it. Keys of
+// Config.Methods are full method names ("/pkg.Service/Method") or a service
+// wildcard ("/pkg.Service/*").
+type Method struct {
+	EventType   string
+	Category    string
+	Description string
+}
+
+type Interceptor struct {
+	config *Config
+}
+
+func New(cfg *Config) (*Interceptor, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	if cfg.RequestIDKey == "" {
+		cfg.RequestIDKey = DefaultRequestIDKey
+	}
+	if cfg.SessionKey == "" {
+		cfg.SessionKey = DefaultSessionKey
+	}
+
+	for name, method := range cfg.Methods {
+		if method.EventType == "" {
+			return nil, fmt.Errorf("auditgrpc: method %s has no event type", name)
+		}
+	}
+	if len(cfg.Methods) > 0 && cfg.Client == nil {
+		return nil, fmt.Errorf("auditgrpc: methods are configured but no client is set")
+	}
+
+	return &Interceptor{config: cfg}, nil
+}
+
+func (i *Interceptor) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx = i.scope(ctx)
+
+		method, ok := i.lookup(info.FullMethod)
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		i.emit(ctx, info.FullMethod, method, err, time.Since(start), nil)
+		return resp, err
+	}
+}
+
+func (i *Interceptor) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		stream := &serverStream{ServerStream: ss, ctx: i.scope(ss.Context())}
+
+		method, ok := i.lookup(info.FullMethod)
+		if !ok {
+			return handler(srv, stream)
+		}
+
+		start := time.Now()
+		err := handler(srv, stream)
+
+		i.emit(stream.ctx, info.FullMethod, method, err, time.Since(start), audit.Metadata{
+			"grpc_messages_received": stream.received.Load(),
+			"grpc_messages_sent":     stream.sent.Load(),
+		})
+		return err
+	}
+}
+
+func (i *Interceptor) scope(ctx context.Context) context.Context {
+	ctx, scope := audit.NewScope(ctx, i.auditContext(ctx))
+	if i.config.TeamID != nil {
+		scope.SetTeamID(i.config.TeamID(ctx))
+	}
+	return ctx
+}
+
+func (i *Interceptor) emit(ctx context.Context, fullMethod string, method Method, err error, elapsed time.Duration, extra audit.Metadata) {
+	if scope := audit.ScopeFromContext(ctx); scope == nil || scope.TeamID() == "" {
+		return
+	}
+
+	st := status.Convert(err)
+
+	meta := audit.Metadata{
+		"grpc_method":      fullMethod,
+		"grpc_code":        st.Code().String(),
+		"grpc_duration_ms": elapsed.Milliseconds(),
+	}
+	for key, value := range extra {
+		meta[key] = value
+	}
+
+	event := audit.Event{
+		Event: audit.EventInfo{
+			Type:        method.EventType,
+			Category:    method.Category,
+			Description: method.Description,
+			Status:      "success",
+		},
+		Metadata: &meta,
+	}
+
+	if st.Code() != codes.OK {
+		event.Event.Status = "failure"
+		event.Event.ErrorMessage = st.Message()
+	}
+
+	var emitErr error
+	if e, ok := i.config.Client.(audit.ContextEmitter); ok {
+		emitErr = e.EmitContext(ctx, event)
+	} else {
+		emitErr = i.config.Client.Emit(event)
+	}
+	if emitErr != nil && i.config.OnError != nil {
+		i.config.OnError(ctx, fullMethod, emitErr)
+	}
+}
+
+func (i *Interceptor) lookup(fullMethod string) (Method, bool) {
+	if method, ok := i.config.Methods[fullMethod]; ok {
+		return method, true
+	}
+	if idx := strings.LastIndex(fullMethod, "/"); idx > 0 {
+		if method, ok := i.config.Methods[fullMethod[:idx]+"/*"]; ok {
+			return method, true
+		}
+	}
+	return Method{}, false
+}
+
+func (i *Interceptor) auditContext(ctx context.Context) audit.Context {
+	var auditCtx audit.Context
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		auditCtx.IPAddress = peerIP(p.Addr)
+	}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		auditCtx.UserAgent = first(md, "user-agent")
+		auditCtx.RequestID = first(md, i.config.RequestIDKey)
+		auditCtx.SessionID = first(md, i.config.SessionKey)
+	}
+
+	return auditCtx
+}
+
+func peerIP(addr net.Addr) string {
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		return a.IP.String()
+	case *net.UDPAddr:
+		return a.IP.String()
+	}
+
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		host = addr.String()
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.String()
+	}
+	return ""
+}
+
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	received atomic.Int64
+	sent     atomic.Int64
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *serverStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.received.Add(1)
+	}
+	return err
+}
+
+func (s *serverStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.sent.Add(1)
+	}
+	return err
+}
@@ -0,0 +1,209 @@
+package auditgrpc
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	audit "github.com/NeuralTrust/audit-sdk-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type recordingClient struct {
+	mu     sync.Mutex
+	events []audit.Event
+}
+
+func (c *recordingClient) Emit(event audit.Event) error {
+	return c.EmitContext(context.Background(), event)
+}
+
+func (c *recordingClient) EmitContext(ctx context.Context, event audit.Event) error {
+	if scope := audit.ScopeFromContext(ctx); scope != nil {
+		scope.Apply(&event)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, event)
+	return nil
+}
+
+func (c *recordingClient) Close() error {
+	return nil
+}
+
+func (c *recordingClient) recorded() []audit.Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]audit.Event(nil), c.events...)
+}
+
+type healthServer struct {
+	*health.Server
+}
+
+func (s *healthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	audit.ScopeFromContext(ctx).SetActor(audit.Actor{ID: "svc-1", Type: audit.ActorTypeService})
+	return s.Server.Check(ctx, req)
+}
+
+func (s *healthServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	audit.ScopeFromContext(stream.Context()).SetTarget(audit.Target{Type: "service", ID: req.Service})
+	for i := 0; i < 3; i++ {
+		if err := stream.Send(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func startServer(t *testing.T, cfg *Config) healthpb.HealthClient {
+	t.Helper()
+
+	interceptor, err := New(cfg)
+	require.NoError(t, err)
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(interceptor.UnaryServerInterceptor()),
+		grpc.StreamInterceptor(interceptor.StreamServerInterceptor()),
+	)
+
+	hs := health.NewServer()
+	hs.SetServingStatus("gateway", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, &healthServer{Server: hs})
+
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUserAgent("audit-test"),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return healthpb.NewHealthClient(conn)
+}
+
+func teamFromMetadata(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return first(md, "x-team-id")
+}
+
+func outgoing(pairs ...string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), append([]string{"x-team-id", "team-1"}, pairs...)...)
+}
+
+func TestUnaryInterceptor_EmitsEvents(t *testing.T) {
+	client := &recordingClient{}
+	hc := startServer(t, &Config{
+		Client: client,
+		TeamID: teamFromMetadata,
+		Methods: map[string]Method{
+			healthpb.Health_Check_FullMethodName: {EventType: "health.checked", Category: "health"},
+		},
+	})
+
+	_, err := hc.Check(outgoing("x-request-id", "req-1", "x-session-id", "sess-1"), &healthpb.HealthCheckRequest{Service: "gateway"})
+	require.NoError(t, err)
+
+	_, err = hc.Check(outgoing(), &healthpb.HealthCheckRequest{Service: "missing"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	events := client.recorded()
+	require.Len(t, events, 2)
+
+	ok := events[0]
+	assert.Equal(t, "team-1", ok.TeamID)
+	assert.Equal(t, "health.checked", ok.Event.Type)
+	assert.Equal(t, "health", ok.Event.Category)
+	assert.Equal(t, "success", ok.Event.Status)
+	assert.Equal(t, "svc-1", ok.Actor.ID)
+	require.NotNil(t, ok.Context)
+	assert.Equal(t, "req-1", ok.Context.RequestID)
+	assert.Equal(t, "sess-1", ok.Context.SessionID)
+	assert.Contains(t, ok.Context.UserAgent, "audit-test")
+	assert.Equal(t, "OK", (*ok.Metadata)["grpc_code"])
+	assert.Equal(t, healthpb.Health_Check_FullMethodName, (*ok.Metadata)["grpc_method"])
+
+	failed := events[1]
+	assert.Equal(t, "failure", failed.Event.Status)
+	assert.Equal(t, "unknown service", failed.Event.ErrorMessage)
+	assert.Equal(t, "NotFound", (*failed.Metadata)["grpc_code"])
+}
+
+func TestStreamInterceptor_EmitsOneEventPerStream(t *testing.T) {
+	client := &recordingClient{}
+	hc := startServer(t, &Config{
+		Client: client,
+		TeamID: teamFromMetadata,
+		Methods: map[string]Method{
+			"/grpc.health.v1.Health/*": {EventType: "health.watched", Category: "health"},
+		},
+	})
+
+	stream, err := hc.Watch(outgoing(), &healthpb.HealthCheckRequest{Service: "gateway"})
+	require.NoError(t, err)
+
+	received := 0
+	for {
+		if _, err := stream.Recv(); err != nil {
+			break
+		}
+		received++
+	}
+	assert.Equal(t, 3, received)
+
+	require.Eventually(t, func() bool { return len(client.recorded()) == 1 }, time.Second, 10*time.Millisecond)
+
+	event := client.recorded()[0]
+	assert.Equal(t, "health.watched", event.Event.Type)
+	assert.Equal(t, "success", event.Event.Status)
+	assert.Equal(t, "gateway", event.Target.ID)
+	assert.Equal(t, int64(1), (*event.Metadata)["grpc_messages_received"])
+	assert.Equal(t, int64(3), (*event.Metadata)["grpc_messages_sent"])
+}
+
+func TestInterceptor_UnmappedMethodsOnlyGetScope(t *testing.T) {
+	client := &recordingClient{}
+	hc := startServer(t, &Config{Client: client, TeamID: teamFromMetadata})
+
+	_, err := hc.Check(outgoing(), &healthpb.HealthCheckRequest{Service: "gateway"})
+	require.NoError(t, err)
+
+	assert.Empty(t, client.recorded())
+}
+
+func TestNew_Validation(t *testing.T) {
+	_, err := New(&Config{Client: &recordingClient{}, Methods: map[string]Method{"/a.B/C": {}}})
+	assert.Error(t, err)
+
+	_, err = New(&Config{Methods: map[string]Method{"/a.B/C": {EventType: "x"}}})
+	assert.Error(t, err)
+}
+
+func TestPeerIP(t *testing.T) {
+	assert.Equal(t, "192.0.2.1", peerIP(&net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 1234}))
+	assert.Equal(t, "2001:db8::1", peerIP(&net.UDPAddr{IP: net.ParseIP("2001:db8::1"), Port: 53}))
+	assert.Equal(t, "", peerIP(bufconnAddr{}))
+}
+
+type bufconnAddr struct{}
+
+func (bufconnAddr) Network() string { return "bufconn" }
+func (bufconnAddr) String() string  { return "bufconn" }
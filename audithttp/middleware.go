@@ -0,0 +1,262 @@
+package audithttp
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	audit "github.com/NeuralTrust/audit-sdk-go"
+)
+
+const (
+	DefaultRequestIDHeader = "X-Request-ID"
+	DefaultSessionHeader   = "X-Session-ID"
+)
+
+type Config struct {
+	Client          audit.Client
+	TrustedProxies  []string
+	Routes          []Route
+	TeamID          func(r *http.Request) string
+	RequestIDHeader string
+	SessionHeader   string
+	SessionCookie   string
+	OnError         func(r *http.Request, err error)
+}
+
+type Route struct {
+	Method      string
+	Path        string
+	EventType   string
+	Category    string
+	Description string
+}
+
+type Middleware struct {
+	config  *Config
+	proxies []*net.IPNet
+}
+
+func New(cfg *Config) (*Middleware, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	if cfg.RequestIDHeader == "" {
+		cfg.RequestIDHeader = DefaultRequestIDHeader
+	}
+	if cfg.SessionHeader == "" {
+		cfg.SessionHeader = DefaultSessionHeader
+	}
+
+	for _, route := range cfg.Routes {
+		if route.EventType == "" {
+			return nil, fmt.Errorf("audithttp: route %s %s has no event type", route.Method, route.Path)
+		}
+	}
+	if len(cfg.Routes) > 0 && cfg.Client == nil {
+		return nil, fmt.Errorf("audithttp: routes are configured but no client is set")
+	}
+
+	proxies, err := parseProxies(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Middleware{config: cfg, proxies: proxies}, nil
+}
+
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, scope := audit.NewScope(r.Context(), m.auditContext(r))
+		if m.config.TeamID != nil {
+			scope.SetTeamID(m.config.TeamID(r))
+		}
+		r = r.WithContext(ctx)
+
+		route, ok := m.match(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rw, r)
+
+		m.emit(r, route, rw.status, time.Since(start))
+	})
+}
+
+func (m *Middleware) emit(r *http.Request, route Route, status int, elapsed time.Duration) {
+	if scope := audit.ScopeFromContext(r.Context()); scope == nil || scope.TeamID() == "" {
+		return
+	}
+
+	event := audit.Event{
+		Event: audit.EventInfo{
+			Type:        route.EventType,
+			Category:    route.Category,
+			Description: route.Description,
+			Status:      "success",
+		},
+		Metadata: &audit.Metadata{
+			"http_method":      r.Method,
+			"http_path":        r.URL.Path,
+			"http_status":      status,
+			"http_duration_ms": elapsed.Milliseconds(),
+		},
+	}
+
+	if status >= http.StatusBadRequest {
+		event.Event.Status = "failure"
+		event.Event.ErrorMessage = http.StatusText(status)
+	}
+
+	var err error
+	if e, ok := m.config.Client.(audit.ContextEmitter); ok {
+		err = e.EmitContext(r.Context(), event)
+	} else {
+		err = m.config.Client.Emit(event)
+	}
+	if err != nil && m.config.OnError != nil {
+		m.config.OnError(r, err)
+	}
+}
+
+func (m *Middleware) match(r *http.Request) (Route, bool) {
+	for _, route := range m.config.Routes {
+		if route.Method != "" && !strings.EqualFold(route.Method, r.Method) {
+			continue
+		}
+		if prefix, ok := strings.CutSuffix(route.Path, "*"); ok {
+			if strings.HasPrefix(r.URL.Path, prefix) {
+				return route, true
+			}
+			continue
+		}
+		if route.Path == r.URL.Path {
+			return route, true
+		}
+	}
+	return Route{}, false
+}
+
+func (m *Middleware) auditContext(r *http.Request) audit.Context {
+	auditCtx := audit.Context{
+		IPAddress: m.clientIP(r),
+		UserAgent: r.UserAgent(),
+		RequestID: r.Header.Get(m.config.RequestIDHeader),
+		SessionID: r.Header.Get(m.config.SessionHeader),
+	}
+
+	if auditCtx.SessionID == "" && m.config.SessionCookie != "" {
+		if cookie, err := r.Cookie(m.config.SessionCookie); err == nil {
+			auditCtx.SessionID = cookie.Value
+		}
+	}
+
+	return auditCtx
+}
+
+// clientIP returns the address of the peer, unless the peer is a trusted
+// proxy. In that case X-Forwarded-For is walked from the right and the first
+// address that is not a trusted proxy is returned.
+func (m *Middleware) clientIP(r *http.Request) string {
+	remote := parseIP(r.RemoteAddr)
+	if remote == nil {
+		return ""
+	}
+	if !m.trusted(remote) {
+		return remote.String()
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+
+	client := remote
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := parseIP(strings.TrimSpace(hops[i]))
+		if ip == nil {
+			break
+		}
+		client = ip
+		if !m.trusted(ip) {
+			break
+		}
+	}
+
+	return client.String()
+}
+
+func (m *Middleware) trusted(ip net.IP) bool {
+	for _, network := range m.proxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseProxies(proxies []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("audithttp: invalid trusted proxy %q", proxy)
+			}
+			bits := 32
+			if ip.To4() == nil {
+				bits = 128
+			}
+			proxy = fmt.Sprintf("%s/%d", ip.String(), bits)
+		}
+
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("audithttp: invalid trusted proxy %q: %w", proxy, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+func parseIP(addr string) net.IP {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	return net.ParseIP(strings.Trim(addr, "[]"))
+}
+
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,246 @@
+package audithttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	audit "github.com/NeuralTrust/audit-sdk-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingClient struct {
+	mu     sync.Mutex
+	events []audit.Event
+	err    error
+}
+
+func (c *recordingClient) Emit(event audit.Event) error {
+	return c.EmitContext(context.Background(), event)
+}
+
+func (c *recordingClient) EmitContext(ctx context.Context, event audit.Event) error {
+	if scope := audit.ScopeFromContext(ctx); scope != nil {
+		scope.Apply(&event)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, event)
+	return c.err
+}
+
+func (c *recordingClient) Close() error {
+	return nil
+}
+
+func newMiddleware(t *testing.T, cfg *Config) *Middleware {
+	t.Helper()
+
+	m, err := New(cfg)
+	require.NoError(t, err)
+	return m
+}
+
+func TestMiddleware_PopulatesScope(t *testing.T) {
+	m := newMiddleware(t, &Config{SessionCookie: "sid"})
+
+	var captured audit.Context
+	handler := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scope := audit.ScopeFromContext(r.Context())
+		require.NotNil(t, scope)
+		captured = scope.Context()
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/gateways", nil)
+	req.RemoteAddr = "203.0.113.7:51234"
+	req.Header.Set("User-Agent", "curl/8.0")
+	req.Header.Set("X-Request-ID", "req-1")
+	req.AddCookie(&http.Cookie{Name: "sid", Value: "session-1"})
+
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, audit.Context{
+		IPAddress: "203.0.113.7",
+		UserAgent: "curl/8.0",
+		SessionID: "session-1",
+		RequestID: "req-1",
+	}, captured)
+}
+
+func TestMiddleware_ClientIP(t *testing.T) {
+	m := newMiddleware(t, &Config{TrustedProxies: []string{"10.0.0.0/8", "2001:db8::1"}})
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		want       string
+	}{
+		{
+			name:       "untrusted peer ignores forwarded header",
+			remoteAddr: "198.51.100.1:443",
+			forwarded:  []string{"1.2.3.4"},
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "trusted peer uses rightmost untrusted hop",
+			remoteAddr: "10.0.0.5:443",
+			forwarded:  []string{"1.2.3.4, 5.6.7.8, 10.0.0.9"},
+			want:       "5.6.7.8",
+		},
+		{
+			name:       "multiple forwarded headers",
+			remoteAddr: "10.0.0.5:443",
+			forwarded:  []string{"1.2.3.4", "10.1.1.1"},
+			want:       "1.2.3.4",
+		},
+		{
+			name:       "ipv6 trusted proxy",
+			remoteAddr: "[2001:db8::1]:443",
+			forwarded:  []string{"2001:db8::42"},
+			want:       "2001:db8::42",
+		},
+		{
+			name:       "all hops trusted",
+			remoteAddr: "10.0.0.5:443",
+			forwarded:  []string{"10.0.0.6"},
+			want:       "10.0.0.6",
+		},
+		{
+			name:       "garbage hop stops the walk",
+			remoteAddr: "10.0.0.5:443",
+			forwarded:  []string{"1.2.3.4, nonsense"},
+			want:       "10.0.0.5",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			assert.Equal(t, tt.want, m.clientIP(req))
+		})
+	}
+}
+
+func TestMiddleware_EmitsForConfiguredRoutes(t *testing.T) {
+	client := &recordingClient{}
+	m := newMiddleware(t, &Config{
+		Client: client,
+		TeamID: func(r *http.Request) string { return r.Header.Get("X-Team-ID") },
+		Routes: []Route{
+			{Method: http.MethodPost, Path: "/gateways", EventType: "gateway.created", Category: "gateway"},
+			{Method: http.MethodDelete, Path: "/gateways/*", EventType: "gateway.deleted", Category: "gateway"},
+		},
+	})
+
+	handler := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scope := audit.ScopeFromContext(r.Context())
+		scope.SetActor(audit.Actor{ID: "user-1", Type: audit.ActorTypeUser})
+		scope.SetTarget(audit.Target{Type: "gateway", ID: "gw-1"})
+		scope.AddMetadata("region", "eu")
+
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodPost, "/gateways", nil),
+		httptest.NewRequest(http.MethodDelete, "/gateways/gw-1", nil),
+		httptest.NewRequest(http.MethodGet, "/gateways", nil),
+	} {
+		req.Header.Set("X-Team-ID", "team-1")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	require.Len(t, client.events, 2)
+
+	created := client.events[0]
+	assert.Equal(t, "team-1", created.TeamID)
+	assert.Equal(t, "gateway.created", created.Event.Type)
+	assert.Equal(t, "success", created.Event.Status)
+	assert.Equal(t, "user-1", created.Actor.ID)
+	assert.Equal(t, "gw-1", created.Target.ID)
+	assert.Equal(t, "eu", (*created.Metadata)["region"])
+	assert.Equal(t, http.StatusCreated, (*created.Metadata)["http_status"])
+	require.NotNil(t, created.Context)
+	assert.Equal(t, "192.0.2.1", created.Context.IPAddress)
+
+	deleted := client.events[1]
+	assert.Equal(t, "gateway.deleted", deleted.Event.Type)
+	assert.Equal(t, "failure", deleted.Event.Status)
+	assert.Equal(t, "Forbidden", deleted.Event.ErrorMessage)
+}
+
+func TestMiddleware_SkipsWithoutTeam(t *testing.T) {
+	client := &recordingClient{}
+	m := newMiddleware(t, &Config{
+		Client: client,
+		Routes: []Route{{Path: "/*", EventType: "request"}},
+	})
+
+	handler := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Empty(t, client.events)
+
+	handler = m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		audit.ScopeFromContext(r.Context()).SetTeamID("team-2")
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Len(t, client.events, 1)
+	assert.Equal(t, "team-2", client.events[0].TeamID)
+}
+
+func TestMiddleware_ReportsEmitErrors(t *testing.T) {
+	client := &recordingClient{err: errors.New("boom")}
+
+	var reported error
+	m := newMiddleware(t, &Config{
+		Client:  client,
+		TeamID:  func(*http.Request) string { return "team-1" },
+		Routes:  []Route{{Path: "/", EventType: "request"}},
+		OnError: func(_ *http.Request, err error) { reported = err },
+	})
+
+	m.Handler(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})).
+		ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.EqualError(t, reported, "boom")
+}
+
+func TestMiddleware_ClientWithoutContext(t *testing.T) {
+	client := &recordingClient{}
+	m := newMiddleware(t, &Config{
+		// Only Emit and Close are promoted from the embedded interface.
+		Client: struct{ audit.Client }{client},
+		TeamID: func(*http.Request) string { return "team-1" },
+		Routes: []Route{{Path: "/", EventType: "request"}},
+	})
+
+	m.Handler(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})).
+		ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.Len(t, client.events, 1)
+	assert.Equal(t, "request", client.events[0].Event.Type)
+}
+
+func TestNew_Validation(t *testing.T) {
+	_, err := New(&Config{TrustedProxies: []string{"not-an-ip"}})
+	assert.Error(t, err)
+
+	_, err = New(&Config{Routes: []Route{{Path: "/"}}, Client: &recordingClient{}})
+	assert.Error(t, err)
+
+	_, err = New(&Config{Routes: []Route{{Path: "/", EventType: "request"}}})
+	assert.Error(t, err)
+}
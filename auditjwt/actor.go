@@ -0,0 +1,247 @@
+package auditjwt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	audit "github.com/NeuralTrust/audit-sdk-go"
+)
+
+var (
+	ErrMalformedToken = errors.New("auditjwt: malformed token")
+	ErrNoSubject      = errors.New("auditjwt: token has no subject")
+	ErrNoVerifier     = errors.New("auditjwt: no token verifier")
+	ErrUnverified     = errors.New("auditjwt: token verification failed")
+)
+
+// Verifier checks the signature, issuer, audience and expiry of a compact JWT
+// and returns its claims. Wrap the JWT library your authentication layer
+// already uses, for example golang-jwt's Parser with your key function.
+type Verifier func(token string) (map[string]interface{}, error)
+
+type Config struct {
+	SubjectClaim string
+	EmailClaim   string
+	TenantClaims []string
+	TypeRules    []Rule
+	DefaultType  audit.ActorType
+}
+
+// Rule assigns an actor type when a claim matches. A rule with no Values,
+// Prefix or Suffix matches whenever the claim is present. Rules are
+// evaluated in order and the first match wins.
+type Rule struct {
+	Claim  string
+	Values []string
+	Prefix string
+	Suffix string
+	Type   audit.ActorType
+}
+
+// DefaultTypeRules recognize the service-account conventions of common
+// identity providers: client-credentials grants (Auth0), "@clients" subjects
+// and "service-account-" usernames (Keycloak).
+var DefaultTypeRules = []Rule{
+	{Claim: "gty", Values: []string{"client-credentials"}, Type: audit.ActorTypeService},
+	{Claim: "sub", Suffix: "@clients", Type: audit.ActorTypeService},
+	{Claim: "preferred_username", Prefix: "service-account-", Type: audit.ActorTypeService},
+}
+
+type Resolver struct {
+	config *Config
+}
+
+func New(cfg *Config) *Resolver {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	if cfg.SubjectClaim == "" {
+		cfg.SubjectClaim = "sub"
+	}
+	if cfg.EmailClaim == "" {
+		cfg.EmailClaim = "email"
+	}
+	if cfg.TenantClaims == nil {
+		cfg.TenantClaims = []string{"tenant_id", "tid", "org_id"}
+	}
+	if cfg.TypeRules == nil {
+		cfg.TypeRules = DefaultTypeRules
+	}
+	if cfg.DefaultType == "" {
+		cfg.DefaultType = audit.ActorTypeUser
+	}
+	return &Resolver{config: cfg}
+}
+
+var defaultResolver = New(nil)
+
+func ActorFromToken(token string, verify Verifier) (audit.Actor, error) {
+	return defaultResolver.ActorFromToken(token, verify)
+}
+
+func ActorFromUnverifiedToken(token string) (audit.Actor, error) {
+	return defaultResolver.ActorFromUnverifiedToken(token)
+}
+
+func ActorFromClaims(claims map[string]interface{}) (audit.Actor, error) {
+	return defaultResolver.ActorFromClaims(claims)
+}
+
+// ActorFromToken verifies a compact JWT with verify and builds an Actor from
+// its claims. A "Bearer " prefix is stripped before verification.
+func (r *Resolver) ActorFromToken(token string, verify Verifier) (audit.Actor, error) {
+	if verify == nil {
+		return audit.Actor{}, ErrNoVerifier
+	}
+
+	claims, err := verify(trimBearer(token))
+	if err != nil {
+		return audit.Actor{}, fmt.Errorf("%w: %v", ErrUnverified, err)
+	}
+	return r.ActorFromClaims(claims)
+}
+
+// ActorFromUnverifiedToken decodes the payload of a compact JWT without
+// checking its signature, so anyone can forge the identity it returns. Only
+// use it for tokens your authentication layer has already verified.
+func (r *Resolver) ActorFromUnverifiedToken(token string) (audit.Actor, error) {
+	claims, err := DecodeClaims(token)
+	if err != nil {
+		return audit.Actor{}, err
+	}
+	return r.ActorFromClaims(claims)
+}
+
+// ActorFromClaims builds an Actor from verified claims. When the token
+// carries an "act" claim (RFC 8693), the acting party becomes the actor, the
+// token subject is recorded in OnBehalfOf and nested "act" claims become the
+// delegation chain.
+func (r *Resolver) ActorFromClaims(claims map[string]interface{}) (audit.Actor, error) {
+	subject, err := r.actor(claims)
+	if err != nil {
+		return audit.Actor{}, err
+	}
+
+	act, ok := claims["act"].(map[string]interface{})
+	if !ok {
+		return subject, nil
+	}
+
+	actor, err := r.actor(act)
+	if err != nil {
+		return audit.Actor{}, fmt.Errorf("auditjwt: act claim: %w", err)
+	}
+	if actor.TenantID == "" {
+		actor.TenantID = subject.TenantID
+	}
+
+	// Nested "act" claims name earlier actors, most recent first. The
+	// delegation chain runs from the principal towards the actor.
+	var prior []audit.DelegationLink
+	for next, ok := act["act"].(map[string]interface{}); ok; next, ok = next["act"].(map[string]interface{}) {
+		link, err := r.actor(next)
+		if err != nil {
+			return audit.Actor{}, fmt.Errorf("auditjwt: nested act claim: %w", err)
+		}
+		prior = append(prior, audit.DelegationLink{ID: link.ID, Email: link.Email, Type: link.Type})
+	}
+	for i := len(prior) - 1; i >= 0; i-- {
+		actor = actor.ViaLink(prior[i])
+	}
+
+	return actor.ActingFor(subject), nil
+}
+
+func (r *Resolver) actor(claims map[string]interface{}) (audit.Actor, error) {
+	id := stringClaim(claims, r.config.SubjectClaim)
+	if id == "" {
+		return audit.Actor{}, ErrNoSubject
+	}
+
+	actor := audit.Actor{
+		ID:    id,
+		Email: stringClaim(claims, r.config.EmailClaim),
+		Type:  r.actorType(claims),
+	}
+
+	for _, claim := range r.config.TenantClaims {
+		if tenant := stringClaim(claims, claim); tenant != "" {
+			actor.TenantID = tenant
+			break
+		}
+	}
+
+	return actor, nil
+}
+
+func (r *Resolver) actorType(claims map[string]interface{}) audit.ActorType {
+	for _, rule := range r.config.TypeRules {
+		if rule.matches(claims) {
+			return rule.Type
+		}
+	}
+	return r.config.DefaultType
+}
+
+func (r Rule) matches(claims map[string]interface{}) bool {
+	raw, ok := claims[r.Claim]
+	if !ok {
+		return false
+	}
+	if len(r.Values) == 0 && r.Prefix == "" && r.Suffix == "" {
+		return true
+	}
+
+	value := fmt.Sprint(raw)
+	for _, v := range r.Values {
+		if value == v {
+			return true
+		}
+	}
+	if r.Prefix != "" && strings.HasPrefix(value, r.Prefix) {
+		return true
+	}
+	return r.Suffix != "" && strings.HasSuffix(value, r.Suffix)
+}
+
+func stringClaim(claims map[string]interface{}, name string) string {
+	switch v := claims[name].(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case float64:
+		return fmt.Sprintf("%.0f", v)
+	}
+	return ""
+}
+
+// DecodeClaims returns the payload of a compact JWT without verifying it.
+func DecodeClaims(token string) (map[string]interface{}, error) {
+	token = trimBearer(token)
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformedToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedToken, err)
+	}
+
+	var claims map[string]interface{}
+	dec := json.NewDecoder(strings.NewReader(string(payload)))
+	dec.UseNumber()
+	if err := dec.Decode(&claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedToken, err)
+	}
+	return claims, nil
+}
+
+func trimBearer(token string) string {
+	return strings.TrimSpace(strings.TrimPrefix(token, "Bearer "))
+}
@@ -0,0 +1,152 @@
+package auditjwt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	audit "github.com/NeuralTrust/audit-sdk-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func token(t *testing.T, claims map[string]interface{}) string {
+	t.Helper()
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	return header + "." + base64.RawURLEncoding.EncodeToString(payload) + ".signature"
+}
+
+// verifier accepts only tokens signed with the given signature.
+func verifier(signature string) Verifier {
+	return func(token string) (map[string]interface{}, error) {
+		if !strings.HasSuffix(token, "."+signature) {
+			return nil, errors.New("invalid signature")
+		}
+		return DecodeClaims(token)
+	}
+}
+
+func TestActorFromToken_User(t *testing.T) {
+	actor, err := ActorFromToken("Bearer "+token(t, map[string]interface{}{
+		"sub":   "user-1",
+		"email": "user@example.com",
+		"tid":   "tenant-1",
+	}), verifier("signature"))
+	require.NoError(t, err)
+
+	assert.Equal(t, audit.Actor{
+		ID:       "user-1",
+		Email:    "user@example.com",
+		Type:     audit.ActorTypeUser,
+		TenantID: "tenant-1",
+	}, actor)
+}
+
+func TestActorFromClaims_ServiceAccounts(t *testing.T) {
+	tests := []map[string]interface{}{
+		{"sub": "abc@clients", "gty": "client-credentials"},
+		{"sub": "abc@clients"},
+		{"sub": "f3b1", "preferred_username": "service-account-billing"},
+	}
+
+	for _, claims := range tests {
+		actor, err := ActorFromClaims(claims)
+		require.NoError(t, err)
+		assert.Equal(t, audit.ActorTypeService, actor.Type, claims)
+	}
+}
+
+func TestActorFromClaims_Impersonation(t *testing.T) {
+	actor, err := ActorFromClaims(map[string]interface{}{
+		"sub":       "customer-1",
+		"email":     "customer@example.com",
+		"tenant_id": "tenant-1",
+		"act": map[string]interface{}{
+			"sub":   "support-7",
+			"email": "support@neuraltrust.ai",
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "support-7", actor.ID)
+	assert.Equal(t, "support@neuraltrust.ai", actor.Email)
+	assert.Equal(t, "tenant-1", actor.TenantID)
+	require.NotNil(t, actor.OnBehalfOf)
+	assert.Equal(t, "customer-1", actor.OnBehalfOf.ID)
+	assert.Equal(t, "customer@example.com", actor.OnBehalfOf.Email)
+}
+
+func TestResolver_CustomRules(t *testing.T) {
+	r := New(&Config{
+		SubjectClaim: "uid",
+		TenantClaims: []string{"org"},
+		TypeRules: []Rule{
+			{Claim: "client_id", Type: audit.ActorTypeService},
+			{Claim: "role", Values: []string{"system"}, Type: audit.ActorTypeSystem},
+		},
+	})
+
+	actor, err := r.ActorFromClaims(map[string]interface{}{"uid": "svc-1", "client_id": "billing", "org": "acme"})
+	require.NoError(t, err)
+	assert.Equal(t, audit.ActorTypeService, actor.Type)
+	assert.Equal(t, "acme", actor.TenantID)
+
+	actor, err = r.ActorFromClaims(map[string]interface{}{"uid": "cron", "role": "system"})
+	require.NoError(t, err)
+	assert.Equal(t, audit.ActorTypeSystem, actor.Type)
+
+	actor, err = r.ActorFromUnverifiedToken(token(t, map[string]interface{}{"uid": 12345}))
+	require.NoError(t, err)
+	assert.Equal(t, "12345", actor.ID)
+	assert.Equal(t, audit.ActorTypeUser, actor.Type)
+}
+
+func TestActorFromUnverifiedToken_Errors(t *testing.T) {
+	_, err := ActorFromUnverifiedToken("not-a-jwt")
+	assert.ErrorIs(t, err, ErrMalformedToken)
+
+	_, err = ActorFromUnverifiedToken("a.!!!.c")
+	assert.ErrorIs(t, err, ErrMalformedToken)
+
+	_, err = ActorFromUnverifiedToken(token(t, map[string]interface{}{"email": "x@example.com"}))
+	assert.ErrorIs(t, err, ErrNoSubject)
+
+	_, err = ActorFromClaims(map[string]interface{}{"sub": "user-1", "act": map[string]interface{}{}})
+	assert.ErrorIs(t, err, ErrNoSubject)
+}
+
+func TestActorFromToken_RequiresVerification(t *testing.T) {
+	forged := token(t, map[string]interface{}{"sub": "admin"})
+
+	_, err := ActorFromToken(forged, verifier("trusted"))
+	assert.ErrorIs(t, err, ErrUnverified)
+
+	_, err = ActorFromToken(forged, nil)
+	assert.ErrorIs(t, err, ErrNoVerifier)
+}
+
+func TestActorFromClaims_NestedActBecomesDelegation(t *testing.T) {
+	actor, err := ActorFromClaims(map[string]interface{}{
+		"sub": "user-1",
+		"act": map[string]interface{}{
+			"sub": "billing@clients",
+			"act": map[string]interface{}{
+				"sub": "gateway@clients",
+				"act": map[string]interface{}{"sub": "frontend@clients"},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, actor.Validate())
+
+	assert.Equal(t, "billing@clients", actor.ID)
+	assert.Equal(t, "user-1", actor.OnBehalfOf.ID)
+	assert.Equal(t, []audit.DelegationLink{
+		{ID: "frontend@clients", Type: audit.ActorTypeService},
+		{ID: "gateway@clients", Type: audit.ActorTypeService},
+	}, actor.Delegation)
+}
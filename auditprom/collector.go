@@ -0,0 +1,199 @@
+package auditprom
+
+import (
+	"sync"
+	"time"
+
+	audit "github.com/NeuralTrust/audit-sdk-go"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	DefaultNamespace  = "audit"
+	DefaultMaxTeamIDs = 100
+
+	// OtherTeams replaces team IDs seen after MaxTeamIDs distinct values.
+	OtherTeams = "__other__"
+)
+
+var DefaultLatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30}
+
+type Config struct {
+	Namespace      string
+	ConstLabels    prometheus.Labels
+	TeamIDLabel    bool
+	MaxTeamIDs     int
+	LatencyBuckets []float64
+}
+
+// Collector exports the audit pipeline as Prometheus metrics. It implements
+// both prometheus.Collector and audit.Observer:
+//
+//	metrics := auditprom.New(nil)
+//	prometheus.MustRegister(metrics)
+//	client, err := audit.New(&audit.Config{Observer: metrics, ...})
+type Collector struct {
+	config *Config
+
+	emitted       *prometheus.CounterVec
+	rejected      *prometheus.CounterVec
+	encodeErrors  *prometheus.CounterVec
+	produceErrors *prometheus.CounterVec
+	deliveries    *prometheus.CounterVec
+	latency       *prometheus.HistogramVec
+	dropped       *prometheus.CounterVec
+	queueDepth    prometheus.Gauge
+	inFlightBytes prometheus.Gauge
+	spoolDepth    prometheus.Gauge
+	spoolBytes    prometheus.Gauge
+
+	teamsMu sync.Mutex
+	teams   map[string]struct{}
+}
+
+var _ audit.Observer = (*Collector)(nil)
+
+func New(cfg *Config) *Collector {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	if cfg.Namespace == "" {
+		cfg.Namespace = DefaultNamespace
+	}
+	if cfg.MaxTeamIDs == 0 {
+		cfg.MaxTeamIDs = DefaultMaxTeamIDs
+	}
+	if cfg.LatencyBuckets == nil {
+		cfg.LatencyBuckets = DefaultLatencyBuckets
+	}
+
+	eventLabels := []string{"category"}
+	if cfg.TeamIDLabel {
+		eventLabels = append(eventLabels, "team_id")
+	}
+
+	counter := func(name, help string, labels ...string) *prometheus.CounterVec {
+		return prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   cfg.Namespace,
+			Name:        name,
+			Help:        help,
+			ConstLabels: cfg.ConstLabels,
+		}, labels)
+	}
+	gauge := func(name, help string) prometheus.Gauge {
+		return prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   cfg.Namespace,
+			Name:        name,
+			Help:        help,
+			ConstLabels: cfg.ConstLabels,
+		})
+	}
+
+	return &Collector{
+		config:        cfg,
+		emitted:       counter("events_emitted_total", "Audit events handed to the producer.", eventLabels...),
+		rejected:      counter("events_rejected_total", "Audit events rejected by validation, interceptors or size limits.", eventLabels...),
+		encodeErrors:  counter("encode_errors_total", "Audit events that could not be encoded or signed.", "category"),
+		produceErrors: counter("produce_errors_total", "Messages the producer refused to enqueue.", "topic"),
+		deliveries:    counter("deliveries_total", "Broker delivery reports by outcome.", "topic", "outcome"),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   cfg.Namespace,
+			Name:        "delivery_latency_seconds",
+			Help:        "Time from enqueueing a message to its delivery report.",
+			ConstLabels: cfg.ConstLabels,
+			Buckets:     cfg.LatencyBuckets,
+		}, []string{"topic"}),
+		dropped:       counter("events_dropped_total", "Messages discarded because the producer queue was full.", "topic", "reason"),
+		queueDepth:    gauge("queue_depth", "Messages waiting in the producer queue."),
+		inFlightBytes: gauge("in_flight_bytes", "Payload bytes enqueued but not yet delivered."),
+		spoolDepth:    gauge("spool_depth", "Messages waiting in the disk spool."),
+		spoolBytes:    gauge("spool_bytes", "Size of the disk spool in bytes."),
+		teams:         make(map[string]struct{}),
+	}
+}
+
+func (c *Collector) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		c.emitted, c.rejected, c.encodeErrors, c.produceErrors,
+		c.deliveries, c.latency, c.dropped, c.queueDepth, c.inFlightBytes,
+		c.spoolDepth, c.spoolBytes,
+	}
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, collector := range c.collectors() {
+		collector.Describe(ch)
+	}
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for _, collector := range c.collectors() {
+		collector.Collect(ch)
+	}
+}
+
+func (c *Collector) EventEmitted(event *audit.Event) {
+	c.emitted.WithLabelValues(c.eventLabels(event)...).Inc()
+}
+
+func (c *Collector) EventRejected(event *audit.Event, err error) {
+	c.rejected.WithLabelValues(c.eventLabels(event)...).Inc()
+}
+
+func (c *Collector) EncodeFailed(event *audit.Event, err error) {
+	c.encodeErrors.WithLabelValues(event.Event.Category).Inc()
+}
+
+func (c *Collector) ProduceFailed(topic string, err error) {
+	c.produceErrors.WithLabelValues(topic).Inc()
+}
+
+func (c *Collector) Delivered(topic string, latency time.Duration, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "failure"
+	}
+	c.deliveries.WithLabelValues(topic, outcome).Inc()
+	c.latency.WithLabelValues(topic).Observe(latency.Seconds())
+}
+
+func (c *Collector) QueueChanged(depth int, inFlightBytes int64) {
+	c.queueDepth.Set(float64(depth))
+	c.inFlightBytes.Set(float64(inFlightBytes))
+}
+
+func (c *Collector) Dropped(topic string, reason audit.DropReason) {
+	c.dropped.WithLabelValues(topic, string(reason)).Inc()
+}
+
+func (c *Collector) SpoolChanged(depth int, bytes int64) {
+	c.spoolDepth.Set(float64(depth))
+	c.spoolBytes.Set(float64(bytes))
+}
+
+func (c *Collector) eventLabels(event *audit.Event) []string {
+	if !c.config.TeamIDLabel {
+		return []string{event.Event.Category}
+	}
+	return []string{event.Event.Category, c.teamLabel(event.TeamID)}
+}
+
+// teamLabel bounds the cardinality of the team_id label: the first
+// MaxTeamIDs distinct teams are reported as-is, later ones as OtherTeams.
+func (c *Collector) teamLabel(teamID string) string {
+	if c.config.MaxTeamIDs < 0 {
+		return teamID
+	}
+
+	c.teamsMu.Lock()
+	defer c.teamsMu.Unlock()
+
+	if _, ok := c.teams[teamID]; ok {
+		return teamID
+	}
+	if len(c.teams) >= c.config.MaxTeamIDs {
+		return OtherTeams
+	}
+	c.teams[teamID] = struct{}{}
+	return teamID
+}
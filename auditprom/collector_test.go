@@ -0,0 +1,84 @@
+package auditprom
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	audit "github.com/NeuralTrust/audit-sdk-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func event(teamID, category string) *audit.Event {
+	return &audit.Event{TeamID: teamID, Event: audit.EventInfo{Type: "test.event", Category: category}}
+}
+
+func TestCollector_Register(t *testing.T) {
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(New(&Config{TeamIDLabel: true})))
+}
+
+func TestCollector_Events(t *testing.T) {
+	c := New(nil)
+
+	c.EventEmitted(event("team-1", "gateway"))
+	c.EventEmitted(event("team-2", "gateway"))
+	c.EventRejected(event("team-1", "auth"), audit.ErrEmptyEventType)
+	c.EncodeFailed(event("team-1", "auth"), errors.New("boom"))
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(c.emitted.WithLabelValues("gateway")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.rejected.WithLabelValues("auth")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.encodeErrors.WithLabelValues("auth")))
+}
+
+func TestCollector_Delivery(t *testing.T) {
+	c := New(nil)
+
+	c.Delivered("audit_events", 20*time.Millisecond, nil)
+	c.Delivered("audit_events", 40*time.Millisecond, nil)
+	c.Delivered("audit_logs_ingest", time.Second, errors.New("timed out"))
+	c.ProduceFailed("audit_events", errors.New("queue full"))
+	c.QueueChanged(12, 4096)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(c.deliveries.WithLabelValues("audit_events", "success")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.deliveries.WithLabelValues("audit_logs_ingest", "failure")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.produceErrors.WithLabelValues("audit_events")))
+	assert.Equal(t, 12.0, testutil.ToFloat64(c.queueDepth))
+	assert.Equal(t, 4096.0, testutil.ToFloat64(c.inFlightBytes))
+
+	var m dto.Metric
+	require.NoError(t, c.latency.WithLabelValues("audit_events").(prometheus.Metric).Write(&m))
+	assert.Equal(t, uint64(2), m.GetHistogram().GetSampleCount())
+	assert.InDelta(t, 0.06, m.GetHistogram().GetSampleSum(), 1e-9)
+}
+
+func TestCollector_Backpressure(t *testing.T) {
+	c := New(nil)
+
+	c.Dropped("audit_events", audit.DropQueueFull)
+	c.Dropped("audit_events", audit.DropQueueFull)
+	c.Dropped("audit_events", audit.DropSpoolFull)
+	c.SpoolChanged(3, 2048)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(c.dropped.WithLabelValues("audit_events", "queue_full")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.dropped.WithLabelValues("audit_events", "spool_full")))
+	assert.Equal(t, 3.0, testutil.ToFloat64(c.spoolDepth))
+	assert.Equal(t, 2048.0, testutil.ToFloat64(c.spoolBytes))
+}
+
+func TestCollector_TeamIDCardinalityGuard(t *testing.T) {
+	c := New(&Config{TeamIDLabel: true, MaxTeamIDs: 2})
+
+	for _, team := range []string{"team-1", "team-2", "team-3", "team-1", "team-4"} {
+		c.EventEmitted(event(team, "gateway"))
+	}
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(c.emitted.WithLabelValues("gateway", "team-1")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.emitted.WithLabelValues("gateway", "team-2")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(c.emitted.WithLabelValues("gateway", OtherTeams)))
+	assert.Equal(t, 3, testutil.CollectAndCount(c.emitted))
+}
@@ -0,0 +1,259 @@
+package auditua
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	audit "github.com/NeuralTrust/audit-sdk-go"
+)
+
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceBot     = "bot"
+	DeviceCLI     = "cli"
+	DeviceSDK     = "sdk"
+	DeviceLibrary = "library"
+	DeviceUnknown = "unknown"
+)
+
+type Config struct {
+	Patterns []Pattern
+}
+
+// Pattern recognizes one of our own clients. Expression is a regular
+// expression searched for anywhere in the user agent, so anchor it with ^ or
+// $ to match only at the start or end; its first capture group, if any, is
+// reported as the client version.
+type Pattern struct {
+	Name       string
+	Expression string
+	DeviceType string
+}
+
+type Parser struct {
+	patterns []compiledPattern
+}
+
+type compiledPattern struct {
+	name       string
+	re         *regexp.Regexp
+	deviceType string
+}
+
+func New(cfg *Config) (*Parser, error) {
+	p := &Parser{}
+	if cfg == nil {
+		return p, nil
+	}
+
+	for _, pattern := range cfg.Patterns {
+		if pattern.Name == "" {
+			return nil, fmt.Errorf("auditua: pattern %q has no name", pattern.Expression)
+		}
+		re, err := regexp.Compile(pattern.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("auditua: pattern %s: %w", pattern.Name, err)
+		}
+		deviceType := pattern.DeviceType
+		if deviceType == "" {
+			deviceType = DeviceSDK
+		}
+		p.patterns = append(p.patterns, compiledPattern{name: pattern.Name, re: re, deviceType: deviceType})
+	}
+
+	return p, nil
+}
+
+// Interceptor returns an audit.Interceptor that fills
+// Context.UserAgentInfo from Context.UserAgent.
+func (p *Parser) Interceptor() audit.Interceptor {
+	return func(ctx context.Context, event *audit.Event, next audit.Next) error {
+		p.Enrich(event)
+		return next(ctx, event)
+	}
+}
+
+func (p *Parser) Enrich(event *audit.Event) {
+	if event.Context == nil || event.Context.UserAgent == "" || event.Context.UserAgentInfo != nil {
+		return
+	}
+
+	auditCtx := *event.Context
+	auditCtx.UserAgentInfo = p.Parse(auditCtx.UserAgent)
+	event.Context = &auditCtx
+}
+
+func (p *Parser) Parse(ua string) *audit.UserAgentInfo {
+	ua = strings.TrimSpace(ua)
+	if ua == "" {
+		return nil
+	}
+
+	info := &audit.UserAgentInfo{}
+
+	for _, pattern := range p.patterns {
+		match := pattern.re.FindStringSubmatch(ua)
+		if match == nil {
+			continue
+		}
+		info.Client = pattern.name
+		if len(match) > 1 {
+			info.ClientVersion = match[1]
+		}
+		info.DeviceType = pattern.deviceType
+		info.OS, info.OSVersion = parseOS(ua)
+		return info
+	}
+
+	lower := strings.ToLower(ua)
+
+	if name, version, ok := parseTool(ua); ok {
+		info.Client, info.ClientVersion = name, version
+		info.DeviceType = toolDeviceType(name)
+		return info
+	}
+
+	info.Browser, info.BrowserVersion = parseBrowser(ua)
+	info.OS, info.OSVersion = parseOS(ua)
+
+	switch {
+	case isBot(lower):
+		info.Bot = true
+		info.DeviceType = DeviceBot
+	case strings.Contains(ua, "iPad") || strings.Contains(lower, "tablet") ||
+		(strings.Contains(ua, "Android") && !strings.Contains(ua, "Mobile")):
+		info.DeviceType = DeviceTablet
+	case strings.Contains(ua, "Mobile") || strings.Contains(ua, "iPhone") || strings.Contains(ua, "iPod"):
+		info.DeviceType = DeviceMobile
+	case info.OS != "":
+		info.DeviceType = DeviceDesktop
+	default:
+		info.DeviceType = DeviceUnknown
+	}
+
+	return info
+}
+
+var botMarkers = []string{
+	"bot", "crawler", "spider", "slurp", "facebookexternalhit", "headlesschrome", "lighthouse",
+}
+
+func isBot(lower string) bool {
+	for _, marker := range botMarkers {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+var tools = []string{
+	"curl", "Wget", "HTTPie", "PostmanRuntime", "insomnia",
+	"Go-http-client", "python-requests", "python-httpx", "aiohttp", "okhttp", "axios", "node-fetch", "Java", "Apache-HttpClient",
+}
+
+// parseTool recognizes command-line tools and HTTP libraries, whose user
+// agent starts with "name/version".
+func parseTool(ua string) (string, string, bool) {
+	for _, tool := range tools {
+		if strings.HasPrefix(strings.ToLower(ua), strings.ToLower(tool)+"/") {
+			return tool, token(ua[len(tool)+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+func toolDeviceType(name string) string {
+	switch name {
+	case "curl", "Wget", "HTTPie":
+		return DeviceCLI
+	case "PostmanRuntime", "insomnia":
+		return DeviceDesktop
+	default:
+		return DeviceLibrary
+	}
+}
+
+func parseBrowser(ua string) (string, string) {
+	switch {
+	case strings.Contains(ua, "Edg/"):
+		return "Edge", versionAfter(ua, "Edg/")
+	case strings.Contains(ua, "Edge/"):
+		return "Edge", versionAfter(ua, "Edge/")
+	case strings.Contains(ua, "OPR/"):
+		return "Opera", versionAfter(ua, "OPR/")
+	case strings.Contains(ua, "SamsungBrowser/"):
+		return "Samsung Internet", versionAfter(ua, "SamsungBrowser/")
+	case strings.Contains(ua, "FxiOS/"):
+		return "Firefox", versionAfter(ua, "FxiOS/")
+	case strings.Contains(ua, "Firefox/"):
+		return "Firefox", versionAfter(ua, "Firefox/")
+	case strings.Contains(ua, "CriOS/"):
+		return "Chrome", versionAfter(ua, "CriOS/")
+	case strings.Contains(ua, "HeadlessChrome/"):
+		return "Headless Chrome", versionAfter(ua, "HeadlessChrome/")
+	case strings.Contains(ua, "Chrome/"):
+		return "Chrome", versionAfter(ua, "Chrome/")
+	case strings.Contains(ua, "Safari/") && strings.Contains(ua, "Version/"):
+		return "Safari", versionAfter(ua, "Version/")
+	case strings.Contains(ua, "Trident/") || strings.Contains(ua, "MSIE "):
+		if v := versionAfter(ua, "MSIE "); v != "" {
+			return "Internet Explorer", v
+		}
+		return "Internet Explorer", versionAfter(ua, "rv:")
+	}
+	return "", ""
+}
+
+var windowsVersions = map[string]string{
+	"10.0": "10",
+	"6.3":  "8.1",
+	"6.2":  "8",
+	"6.1":  "7",
+	"6.0":  "Vista",
+	"5.1":  "XP",
+}
+
+func parseOS(ua string) (string, string) {
+	switch {
+	case strings.Contains(ua, "Windows NT "):
+		v := versionAfter(ua, "Windows NT ")
+		if name, ok := windowsVersions[v]; ok {
+			v = name
+		}
+		return "Windows", v
+	case strings.Contains(ua, "iPhone OS "):
+		return "iOS", strings.ReplaceAll(versionAfter(ua, "iPhone OS "), "_", ".")
+	case strings.Contains(ua, "iPad") && strings.Contains(ua, "CPU OS "):
+		return "iPadOS", strings.ReplaceAll(versionAfter(ua, "CPU OS "), "_", ".")
+	case strings.Contains(ua, "Android"):
+		return "Android", versionAfter(ua, "Android ")
+	case strings.Contains(ua, "CrOS"):
+		return "ChromeOS", ""
+	case strings.Contains(ua, "Mac OS X"):
+		return "macOS", strings.ReplaceAll(versionAfter(ua, "Mac OS X "), "_", ".")
+	case strings.Contains(ua, "Linux"):
+		return "Linux", ""
+	}
+	return "", ""
+}
+
+func versionAfter(ua, marker string) string {
+	idx := strings.Index(ua, marker)
+	if idx < 0 {
+		return ""
+	}
+	return token(ua[idx+len(marker):])
+}
+
+func token(s string) string {
+	end := strings.IndexAny(s, " ;)(")
+	if end >= 0 {
+		s = s[:end]
+	}
+	return s
+}
@@ -0,0 +1,139 @@
+package auditua
+
+import (
+	"context"
+	"testing"
+
+	audit "github.com/NeuralTrust/audit-sdk-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	p, err := New(nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		ua   string
+		want audit.UserAgentInfo
+	}{
+		{
+			name: "chrome on windows",
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.6478.127 Safari/537.36",
+			want: audit.UserAgentInfo{Browser: "Chrome", BrowserVersion: "126.0.6478.127", OS: "Windows", OSVersion: "10", DeviceType: DeviceDesktop},
+		},
+		{
+			name: "edge on windows",
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36 Edg/126.0.2592.87",
+			want: audit.UserAgentInfo{Browser: "Edge", BrowserVersion: "126.0.2592.87", OS: "Windows", OSVersion: "10", DeviceType: DeviceDesktop},
+		},
+		{
+			name: "safari on macos",
+			ua:   "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Safari/605.1.15",
+			want: audit.UserAgentInfo{Browser: "Safari", BrowserVersion: "17.5", OS: "macOS", OSVersion: "10.15.7", DeviceType: DeviceDesktop},
+		},
+		{
+			name: "firefox on linux",
+			ua:   "Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0",
+			want: audit.UserAgentInfo{Browser: "Firefox", BrowserVersion: "128.0", OS: "Linux", DeviceType: DeviceDesktop},
+		},
+		{
+			name: "safari on iphone",
+			ua:   "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1",
+			want: audit.UserAgentInfo{Browser: "Safari", BrowserVersion: "17.5", OS: "iOS", OSVersion: "17.5.1", DeviceType: DeviceMobile},
+		},
+		{
+			name: "chrome on android phone",
+			ua:   "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.6478.122 Mobile Safari/537.36",
+			want: audit.UserAgentInfo{Browser: "Chrome", BrowserVersion: "126.0.6478.122", OS: "Android", OSVersion: "14", DeviceType: DeviceMobile},
+		},
+		{
+			name: "android tablet",
+			ua:   "Mozilla/5.0 (Linux; Android 13; SM-X710) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36",
+			want: audit.UserAgentInfo{Browser: "Chrome", BrowserVersion: "126.0.0.0", OS: "Android", OSVersion: "13", DeviceType: DeviceTablet},
+		},
+		{
+			name: "googlebot",
+			ua:   "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			want: audit.UserAgentInfo{DeviceType: DeviceBot, Bot: true},
+		},
+		{
+			name: "curl",
+			ua:   "curl/8.7.1",
+			want: audit.UserAgentInfo{Client: "curl", ClientVersion: "8.7.1", DeviceType: DeviceCLI},
+		},
+		{
+			name: "go http client",
+			ua:   "Go-http-client/2.0",
+			want: audit.UserAgentInfo{Client: "Go-http-client", ClientVersion: "2.0", DeviceType: DeviceLibrary},
+		},
+		{
+			name: "unknown",
+			ua:   "something-else",
+			want: audit.UserAgentInfo{DeviceType: DeviceUnknown},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, &tt.want, p.Parse(tt.ua))
+		})
+	}
+
+	assert.Nil(t, p.Parse("  "))
+}
+
+func TestParse_CustomPatterns(t *testing.T) {
+	p, err := New(&Config{Patterns: []Pattern{
+		{Name: "neuraltrust-cli", Expression: `^neuraltrust-cli/(\S+)`, DeviceType: DeviceCLI},
+		{Name: "audit-sdk-go", Expression: `audit-sdk-go/(\S+)`},
+	}})
+	require.NoError(t, err)
+
+	assert.Equal(t, &audit.UserAgentInfo{
+		Client: "neuraltrust-cli", ClientVersion: "2.3.0", DeviceType: DeviceCLI, OS: "macOS",
+	}, p.Parse("neuraltrust-cli/2.3.0 (Mac OS X; arm64)"))
+
+	assert.Equal(t, &audit.UserAgentInfo{
+		Client: "audit-sdk-go", ClientVersion: "0.1.0", DeviceType: DeviceSDK,
+	}, p.Parse("Go-http-client/1.1 audit-sdk-go/0.1.0"))
+}
+
+func TestParse_PatternsAreUnanchored(t *testing.T) {
+	p, err := New(&Config{Patterns: []Pattern{
+		{Name: "neuraltrust-cli", Expression: `^neuraltrust-cli/(\S+)`, DeviceType: DeviceCLI},
+		{Name: "curl-wrapper", Expression: `curl/(\S+)`},
+	}})
+	require.NoError(t, err)
+
+	assert.NotEqual(t, "neuraltrust-cli", p.Parse("x-neuraltrust-cli/2.3.0").Client)
+	assert.Equal(t, "curl-wrapper", p.Parse("notcurl/1.0").Client)
+}
+
+func TestNew_InvalidPattern(t *testing.T) {
+	_, err := New(&Config{Patterns: []Pattern{{Name: "bad", Expression: "("}}})
+	assert.Error(t, err)
+
+	_, err = New(&Config{Patterns: []Pattern{{Expression: "x"}}})
+	assert.Error(t, err)
+}
+
+func TestInterceptor(t *testing.T) {
+	p, err := New(nil)
+	require.NoError(t, err)
+
+	original := &audit.Context{UserAgent: "curl/8.7.1"}
+	event := audit.Event{Context: original}
+
+	var seen *audit.Event
+	require.NoError(t, p.Interceptor()(context.Background(), &event, func(ctx context.Context, event *audit.Event) error {
+		seen = event
+		return nil
+	}))
+
+	require.NotNil(t, seen.Context.UserAgentInfo)
+	assert.Equal(t, "curl", seen.Context.UserAgentInfo.Client)
+	assert.Equal(t, "curl/8.7.1", seen.Context.UserAgent)
+	assert.Nil(t, original.UserAgentInfo, "caller's context must not be modified")
+}
@@ -0,0 +1,220 @@
+package auditverify
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+)
+
+type JWK struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use,omitempty"`
+	Alg     string `json:"alg,omitempty"`
+	Curve   string `json:"crv,omitempty"`
+	X       string `json:"x,omitempty"`
+	Y       string `json:"y,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+type KeyResolver interface {
+	PublicKey(keyID string) (crypto.PublicKey, error)
+}
+
+type KeySet struct {
+	keys map[string]crypto.PublicKey
+}
+
+func ParseJWKS(data []byte) (*KeySet, error) {
+	var set JWKS
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("auditverify: invalid JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.KeyID == "" {
+			return nil, fmt.Errorf("auditverify: JWKS key without kid")
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			return nil, fmt.Errorf("auditverify: key %q: %w", jwk.KeyID, err)
+		}
+		keys[jwk.KeyID] = key
+	}
+
+	return &KeySet{keys: keys}, nil
+}
+
+func LoadJWKS(path string) (*KeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseJWKS(data)
+}
+
+func (k *KeySet) PublicKey(keyID string) (crypto.PublicKey, error) {
+	key, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, keyID)
+	}
+	return key, nil
+}
+
+func (k *KeySet) Len() int {
+	return len(k.keys)
+}
+
+// JWKSFile resolves keys from a JWKS file that is re-read when an unknown key
+// ID is requested. Keys that disappear from the file are retained, so events
+// signed before a rotation keep verifying after the old key is retired.
+type JWKSFile struct {
+	path        string
+	minInterval time.Duration
+
+	mu         sync.Mutex
+	keys       map[string]crypto.PublicKey
+	lastReload time.Time
+}
+
+func NewJWKSFile(path string) (*JWKSFile, error) {
+	f := &JWKSFile{
+		path:        path,
+		minInterval: 5 * time.Second,
+		keys:        make(map[string]crypto.PublicKey),
+	}
+	if err := f.Reload(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *JWKSFile) Reload() error {
+	set, err := LoadJWKS(f.path)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for kid, key := range set.keys {
+		f.keys[kid] = key
+	}
+	f.lastReload = time.Now()
+	return nil
+}
+
+func (f *JWKSFile) PublicKey(keyID string) (crypto.PublicKey, error) {
+	f.mu.Lock()
+	key, ok := f.keys[keyID]
+	stale := time.Since(f.lastReload) >= f.minInterval
+	f.mu.Unlock()
+
+	if ok {
+		return key, nil
+	}
+
+	if stale {
+		if err := f.Reload(); err != nil {
+			return nil, err
+		}
+		f.mu.Lock()
+		key, ok = f.keys[keyID]
+		f.mu.Unlock()
+		if ok {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnknownKey, keyID)
+}
+
+func (j JWK) PublicKey() (crypto.PublicKey, error) {
+	switch {
+	case j.KeyType == "OKP" && j.Curve == "Ed25519":
+		x, err := decodeSegment(j.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key size %d", len(x))
+		}
+		return ed25519.PublicKey(x), nil
+
+	case j.KeyType == "EC" && j.Curve == "P-256":
+		x, err := decodeSegment(j.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeSegment(j.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, fmt.Errorf("point is not on P-256")
+		}
+		return key, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported key type %s/%s", j.KeyType, j.Curve)
+	}
+}
+
+func NewJWK(keyID string, key crypto.PublicKey) (JWK, error) {
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		return JWK{
+			KeyType: "OKP",
+			KeyID:   keyID,
+			Use:     "sig",
+			Alg:     "EdDSA",
+			Curve:   "Ed25519",
+			X:       base64.RawURLEncoding.EncodeToString(k),
+		}, nil
+
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return JWK{}, fmt.Errorf("auditverify: unsupported curve %s", k.Curve.Params().Name)
+		}
+		x, y := make([]byte, 32), make([]byte, 32)
+		k.X.FillBytes(x)
+		k.Y.FillBytes(y)
+		return JWK{
+			KeyType: "EC",
+			KeyID:   keyID,
+			Use:     "sig",
+			Alg:     "ES256",
+			Curve:   "P-256",
+			X:       base64.RawURLEncoding.EncodeToString(x),
+			Y:       base64.RawURLEncoding.EncodeToString(y),
+		}, nil
+
+	default:
+		return JWK{}, fmt.Errorf("auditverify: unsupported public key type %T", key)
+	}
+}
+
+func decodeSegment(s string) ([]byte, error) {
+	if s == "" {
+		return nil, fmt.Errorf("missing key coordinate")
+	}
+	return base64.RawURLEncoding.DecodeString(s)
+}
@@ -0,0 +1,99 @@
+package auditverify
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+
+	audit "github.com/NeuralTrust/audit-sdk-go"
+)
+
+var (
+	ErrUnsigned         = errors.New("auditverify: event is not signed")
+	ErrUnknownKey       = errors.New("auditverify: unknown signing key")
+	ErrInvalidSignature = errors.New("auditverify: invalid signature")
+	ErrAlgorithm        = errors.New("auditverify: algorithm does not match key")
+)
+
+type Verifier struct {
+	keys KeyResolver
+}
+
+func NewVerifier(keys KeyResolver) *Verifier {
+	return &Verifier{keys: keys}
+}
+
+func (v *Verifier) Verify(event audit.Event) error {
+	if event.Signature == nil {
+		return ErrUnsigned
+	}
+
+	payload, err := audit.SigningPayload(event)
+	if err != nil {
+		return err
+	}
+
+	return v.VerifyPayload(payload, *event.Signature)
+}
+
+func (v *Verifier) VerifyCheckpoint(cp audit.Checkpoint) error {
+	if cp.Signature == nil {
+		return ErrUnsigned
+	}
+
+	payload, err := audit.CheckpointPayload(cp)
+	if err != nil {
+		return err
+	}
+
+	return v.VerifyPayload(payload, *cp.Signature)
+}
+
+func (v *Verifier) VerifyPayload(payload []byte, sig audit.Signature) error {
+	key, err := v.keys.PublicKey(sig.KeyID)
+	if err != nil {
+		return err
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(sig.Value)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		if sig.Algorithm != audit.AlgorithmEdDSA {
+			return ErrAlgorithm
+		}
+		if !ed25519.Verify(k, payload, raw) {
+			return ErrInvalidSignature
+		}
+		return nil
+
+	case *ecdsa.PublicKey:
+		if sig.Algorithm != audit.AlgorithmES256 {
+			return ErrAlgorithm
+		}
+		if len(raw) != 64 {
+			return ErrInvalidSignature
+		}
+		digest := sha256.Sum256(payload)
+		r := new(big.Int).SetBytes(raw[:32])
+		s := new(big.Int).SetBytes(raw[32:])
+		if !ecdsa.Verify(k, digest[:], r, s) {
+			return ErrInvalidSignature
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("auditverify: unsupported public key type %T", key)
+	}
+}
+
+func PublicJWK(signer audit.Signer) (JWK, error) {
+	return NewJWK(signer.KeyID(), signer.Public())
+}
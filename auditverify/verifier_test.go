@@ -0,0 +1,179 @@
+package auditverify
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	audit "github.com/NeuralTrust/audit-sdk-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEd25519Signer(t *testing.T, keyID string) audit.Signer {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := audit.NewEd25519Signer(keyID, key)
+	require.NoError(t, err)
+	return signer
+}
+
+func newECDSASigner(t *testing.T, keyID string) audit.Signer {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	signer, err := audit.NewECDSASigner(keyID, key)
+	require.NoError(t, err)
+	return signer
+}
+
+func writeJWKS(t *testing.T, path string, signers ...audit.Signer) {
+	t.Helper()
+
+	var set JWKS
+	for _, signer := range signers {
+		jwk, err := PublicJWK(signer)
+		require.NoError(t, err)
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	data, err := json.Marshal(set)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+}
+
+func signedEvent(t *testing.T, signer audit.Signer) audit.Event {
+	t.Helper()
+
+	event := audit.Event{
+		ID:       "evt-1",
+		TeamID:   "team-1",
+		Event:    audit.EventInfo{Type: "gateway.created", Status: "success"},
+		Actor:    &audit.Actor{ID: "user-1", Type: audit.ActorTypeUser},
+		Metadata: &audit.Metadata{"region": "eu"},
+	}
+	require.NoError(t, audit.SignEvent(&event, signer))
+
+	data, err := json.Marshal(event)
+	require.NoError(t, err)
+
+	var decoded audit.Event
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	return decoded
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	ed := newEd25519Signer(t, "ed-1")
+	ec := newECDSASigner(t, "ec-1")
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, ed, ec)
+
+	keys, err := LoadJWKS(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, keys.Len())
+
+	verifier := NewVerifier(keys)
+	assert.NoError(t, verifier.Verify(signedEvent(t, ed)))
+	assert.NoError(t, verifier.Verify(signedEvent(t, ec)))
+}
+
+func TestVerifier_DetectsTampering(t *testing.T) {
+	signer := newEd25519Signer(t, "ed-1")
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, signer)
+
+	keys, err := LoadJWKS(path)
+	require.NoError(t, err)
+
+	event := signedEvent(t, signer)
+	event.Event.Status = "failure"
+
+	assert.ErrorIs(t, NewVerifier(keys).Verify(event), ErrInvalidSignature)
+}
+
+func TestVerifier_Errors(t *testing.T) {
+	signer := newEd25519Signer(t, "ed-1")
+	other := newEd25519Signer(t, "ed-2")
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, signer)
+	keys, err := LoadJWKS(path)
+	require.NoError(t, err)
+	verifier := NewVerifier(keys)
+
+	assert.ErrorIs(t, verifier.Verify(audit.Event{ID: "evt-1"}), ErrUnsigned)
+	assert.ErrorIs(t, verifier.Verify(signedEvent(t, other)), ErrUnknownKey)
+
+	event := signedEvent(t, signer)
+	event.Signature.Algorithm = audit.AlgorithmES256
+	assert.ErrorIs(t, verifier.Verify(event), ErrAlgorithm)
+}
+
+func TestJWKSFile_KeyRotation(t *testing.T) {
+	oldKey := newEd25519Signer(t, "2025-01")
+	newKey := newECDSASigner(t, "2025-02")
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, oldKey)
+
+	keys, err := NewJWKSFile(path)
+	require.NoError(t, err)
+	keys.minInterval = 0
+	verifier := NewVerifier(keys)
+
+	oldEvent := signedEvent(t, oldKey)
+	require.NoError(t, verifier.Verify(oldEvent))
+
+	writeJWKS(t, path, newKey)
+
+	assert.NoError(t, verifier.Verify(signedEvent(t, newKey)))
+	assert.NoError(t, verifier.Verify(oldEvent))
+}
+
+func TestParseJWKS_Invalid(t *testing.T) {
+	_, err := ParseJWKS([]byte(`not json`))
+	assert.Error(t, err)
+
+	_, err = ParseJWKS([]byte(`{"keys":[{"kty":"RSA","kid":"r1"}]}`))
+	assert.Error(t, err)
+
+	_, err = ParseJWKS([]byte(`{"keys":[{"kty":"OKP","crv":"Ed25519","x":"AAAA"}]}`))
+	assert.Error(t, err)
+}
+
+func TestVerifier_VerifyCheckpoint(t *testing.T) {
+	signer := newEd25519Signer(t, "ed-1")
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, signer)
+
+	keys, err := LoadJWKS(path)
+	require.NoError(t, err)
+	verifier := NewVerifier(keys)
+
+	cp := audit.Checkpoint{ID: "batch-1", TeamID: "team-1", Count: 3, Root: "abcd"}
+	assert.ErrorIs(t, verifier.VerifyCheckpoint(cp), ErrUnsigned)
+
+	payload, err := audit.CheckpointPayload(cp)
+	require.NoError(t, err)
+	raw, err := signer.Sign(payload)
+	require.NoError(t, err)
+	cp.Signature = &audit.Signature{
+		KeyID:     signer.KeyID(),
+		Algorithm: signer.Algorithm(),
+		Value:     base64.RawURLEncoding.EncodeToString(raw),
+	}
+	assert.NoError(t, verifier.VerifyCheckpoint(cp))
+
+	cp.Count = 2
+	assert.ErrorIs(t, verifier.VerifyCheckpoint(cp), ErrInvalidSignature)
+}
@@ -0,0 +1,71 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/NeuralTrust/audit-sdk-go/kafka"
+)
+
+type (
+	QueueFullPolicy = kafka.QueueFullPolicy
+	DropReason      = kafka.DropReason
+)
+
+const (
+	QueueFullBlock      = kafka.QueueFullBlock
+	QueueFullDropNewest = kafka.QueueFullDropNewest
+	QueueFullDropOldest = kafka.QueueFullDropOldest
+	QueueFullSpool      = kafka.QueueFullSpool
+
+	DropQueueFull    = kafka.DropQueueFull
+	DropBlockTimeout = kafka.DropBlockTimeout
+	DropOverflow     = kafka.DropOverflow
+	DropSpoolFull    = kafka.DropSpoolFull
+	DropSpoolError   = kafka.DropSpoolError
+	DropClosed       = kafka.DropClosed
+)
+
+type dropReporter interface {
+	Dropped() int64
+}
+
+type spoolReporter interface {
+	Spool() (int, int64)
+}
+
+func (c *client) handleDrop(report kafka.DropReport) {
+	c.observer().Dropped(report.Topic, report.Reason)
+	c.queueChanged()
+	c.deliveries.record(time.Now(), true)
+
+	err := fmt.Errorf("%w: %s", ErrEventDropped, report.Reason)
+	if report.Err != nil {
+		err = fmt.Errorf("%w: %s: %w", ErrEventDropped, report.Reason, report.Err)
+	}
+	if c.checkpointReport(report.Topic, report.Key, err) {
+		return
+	}
+
+	var event Event
+	if len(report.Value) > 0 {
+		if err := json.Unmarshal(report.Value, &event); err != nil {
+			c.logger.Warn("failed to decode dropped audit message",
+				slog.String("topic", report.Topic),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+
+	c.traceDelivery(report.Opaque, report.Topic, 0, err)
+	c.handleError(event, err, "dropped audit event",
+		slog.String("topic", report.Topic),
+		slog.String("reason", string(report.Reason)),
+	)
+
+	if c.config.OnDrop != nil {
+		c.config.OnDrop(event, report.Reason)
+	}
+}
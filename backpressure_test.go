@@ -0,0 +1,64 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/NeuralTrust/audit-sdk-go/kafka"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type spoolingProducer struct {
+	queueingProducer
+	spooled int
+}
+
+func (p *spoolingProducer) Spool() (int, int64) {
+	return p.spooled, int64(p.spooled) * 100
+}
+
+func TestClient_HandleDrop(t *testing.T) {
+	observer := &recordingObserver{}
+	var dropped []DropReason
+	var errs []error
+	c := newTestClient(&spoolingProducer{spooled: 4}, &Config{}, "events", "logs")
+	c.config = &Config{
+		Observer: observer,
+		OnDrop: func(event Event, reason DropReason) {
+			assert.Equal(t, "evt-1", event.ID)
+			dropped = append(dropped, reason)
+		},
+		OnError: func(event Event, err error) {
+			errs = append(errs, err)
+		},
+	}
+
+	value, err := json.Marshal(Event{ID: "evt-1", TeamID: "team-1", Event: EventInfo{Type: "test.event"}})
+	require.NoError(t, err)
+
+	c.handleDrop(kafka.DropReport{Topic: "events", Value: value, Reason: DropQueueFull})
+	c.handleDrop(kafka.DropReport{Topic: "events", Value: value, Reason: DropSpoolError, Err: errors.New("disk full")})
+
+	assert.Equal(t, []DropReason{DropQueueFull, DropSpoolError}, dropped)
+	require.Len(t, errs, 2)
+	assert.ErrorIs(t, errs[0], ErrEventDropped)
+	assert.ErrorContains(t, errs[1], "disk full")
+	assert.Equal(t, []string{"dropped:events:queue_full", "dropped:events:spool_error"}, observer.calls)
+	assert.Equal(t, 4, observer.spool)
+
+	total, failures := c.deliveries.counts(time.Now())
+	assert.Equal(t, 2, total)
+	assert.Equal(t, 2, failures)
+}
+
+func TestConfig_SpoolRequiresDir(t *testing.T) {
+	cfg := &Config{Brokers: []string{"localhost:9092"}, QueueFullPolicy: QueueFullSpool}
+	cfg.setDefaults()
+	assert.ErrorIs(t, cfg.validate(), ErrNoSpoolDir)
+
+	cfg.SpoolDir = t.TempDir()
+	assert.NoError(t, cfg.validate())
+}
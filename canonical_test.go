@@ -0,0 +1,113 @@
+package audit
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func canonicalize(t *testing.T, input string) string {
+	t.Helper()
+
+	var v interface{}
+	require.NoError(t, json.Unmarshal([]byte(input), &v))
+
+	out, err := canonicalJSON(v)
+	require.NoError(t, err)
+	return string(out)
+}
+
+func TestCanonicalJSON_RFC8785Example(t *testing.T) {
+	input := `{
+		"numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+		"string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+		"literals": [null, true, false]
+	}`
+
+	expected := `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`
+
+	assert.Equal(t, expected, canonicalize(t, input))
+}
+
+func TestCanonicalJSON_SortsKeysByUTF16(t *testing.T) {
+	input := `{"\u20ac":"Euro","\r":"CR","\ufb33":"Hebrew","1":"One","\ud83d\ude00":"Smiley","\u0080":"Control","\u00f6":"Latin"}`
+
+	expected := "{\"\\r\":\"CR\",\"1\":\"One\",\"\u0080\":\"Control\",\"\u00f6\":\"Latin\",\"\u20ac\":\"Euro\",\"\U0001F600\":\"Smiley\",\"\ufb33\":\"Hebrew\"}"
+
+	assert.Equal(t, expected, canonicalize(t, input))
+}
+
+func TestFormatCanonicalNumber(t *testing.T) {
+	tests := []struct {
+		in   float64
+		want string
+	}{
+		{0, "0"},
+		{math.Copysign(0, -1), "0"},
+		{1, "1"},
+		{-1.5, "-1.5"},
+		{4.5, "4.5"},
+		{0.000001, "0.000001"},
+		{1e-7, "1e-7"},
+		{123e-20, "1.23e-18"},
+		{1e20, "100000000000000000000"},
+		{1e21, "1e+21"},
+		{9007199254740992, "9007199254740992"},
+		{5e-324, "5e-324"},
+		{1.7976931348623157e308, "1.7976931348623157e+308"},
+	}
+
+	for _, tt := range tests {
+		got, err := formatCanonicalNumber(tt.in)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got, "%v", tt.in)
+	}
+
+	_, err := formatCanonicalNumber(math.NaN())
+	assert.Error(t, err)
+	_, err = formatCanonicalNumber(math.Inf(1))
+	assert.Error(t, err)
+}
+
+func TestCanonicalJSON_Event(t *testing.T) {
+	event := Event{
+		Version:   Version,
+		ID:        "evt-1",
+		Timestamp: Timestamp{Time: time.Date(2025, 1, 2, 3, 4, 5, 6000, time.UTC)},
+		TeamID:    "team-1",
+		Event:     EventInfo{Type: "a<b>&c", Status: "success"},
+		Metadata:  &Metadata{"b": 1.0, "a": map[string]interface{}{"z": 2e-7, "y": "é"}},
+	}
+
+	out, err := CanonicalJSON(event)
+	require.NoError(t, err)
+
+	expected := `{"actor":null,"context":null,"event":{"category":"","description":"","status":"success","type":"a<b>&c"},` +
+		`"id":"evt-1","metadata":{"a":{"y":"é","z":2e-7},"b":1},"target":{"id":"","type":""},"team_id":"team-1",` +
+		`"timestamp":"2025-01-02 03:04:05.000006","version":"1.0"}`
+	assert.Equal(t, expected, string(out))
+}
+
+func TestCanonicalJSON_StableAcrossRoundTrip(t *testing.T) {
+	event := Event{
+		ID:       "evt-1",
+		TeamID:   "team-1",
+		Event:    EventInfo{Type: "test.event"},
+		Metadata: &Metadata{"ratio": 0.1, "count": 3, "big": 1e21, "nested": []interface{}{1, "two", nil}},
+	}
+
+	first, err := CanonicalJSON(event)
+	require.NoError(t, err)
+
+	var decoded Event
+	require.NoError(t, json.Unmarshal(first, &decoded))
+
+	second, err := CanonicalJSON(decoded)
+	require.NoError(t, err)
+
+	assert.Equal(t, string(first), string(second))
+}
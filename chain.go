@@ -0,0 +1,301 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+type ChainIssueKind string
+
+const (
+	ChainIssueGap        ChainIssueKind = "gap"
+	ChainIssueReordered  ChainIssueKind = "reordered"
+	ChainIssueModified   ChainIssueKind = "modified"
+	ChainIssueBrokenLink ChainIssueKind = "broken_link"
+	ChainIssueDuplicate  ChainIssueKind = "duplicate"
+	ChainIssueUnchained  ChainIssueKind = "unchained"
+)
+
+type ChainIssue struct {
+	Kind     ChainIssueKind
+	ChainID  string
+	EventID  string
+	Sequence uint64
+	Detail   string
+}
+
+type ChainReport struct {
+	Chains int
+	Events int
+	Issues []ChainIssue
+}
+
+func (r ChainReport) OK() bool {
+	return len(r.Issues) == 0
+}
+
+type hashChain struct {
+	producerID string
+	perTeam    bool
+	mu         sync.Mutex
+	chains     map[string]*chainState
+}
+
+// chainState serializes the events of one chain. Events of different chains
+// are sealed and emitted concurrently.
+type chainState struct {
+	mu   sync.Mutex
+	head chainHead
+}
+
+type chainHead struct {
+	sequence uint64
+	hash     string
+}
+
+func newHashChain(cfg *HashChainConfig) *hashChain {
+	return &hashChain{
+		producerID: cfg.ProducerID,
+		perTeam:    cfg.PerTeam,
+		chains:     make(map[string]*chainState),
+	}
+}
+
+func (h *hashChain) chainID(teamID string) string {
+	if h.perTeam {
+		return h.producerID + "/" + teamID
+	}
+	return h.producerID
+}
+
+func (h *hashChain) chain(id string) *chainState {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	state, ok := h.chains[id]
+	if !ok {
+		state = &chainState{}
+		h.chains[id] = state
+	}
+	return state
+}
+
+// seal links the event to the head of its chain and runs emit while the chain
+// is locked, so that events leave the client in sequence order. The head only
+// advances when the event reached at least one topic.
+func (h *hashChain) seal(event *Event, emit func() error) error {
+	id := h.chainID(event.TeamID)
+	state := h.chain(id)
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	head := state.head
+
+	event.Integrity = &Integrity{
+		ChainID:    id,
+		ProducerID: h.producerID,
+		Sequence:   head.sequence + 1,
+		PrevHash:   head.hash,
+	}
+
+	hash, err := EventHash(*event)
+	if err != nil {
+		return err
+	}
+	event.Integrity.Hash = hash
+
+	err = emit()
+	if err != nil && !partiallyPublished(err) {
+		return err
+	}
+
+	state.head = chainHead{sequence: event.Integrity.Sequence, hash: hash}
+	return err
+}
+
+// EventHash returns the hex-encoded SHA-256 of the event's canonical encoding,
+// computed with the integrity hash itself left empty and without the
+// signature, which is applied after the event has been chained.
+func EventHash(event Event) (string, error) {
+	event.Signature = nil
+	if event.Integrity != nil {
+		integrity := *event.Integrity
+		integrity.Hash = ""
+		event.Integrity = &integrity
+	}
+
+	data, err := canonicalJSON(event)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func defaultProducerID() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), uuid.New().String()[:8])
+}
+
+type ChainVerifier struct {
+	chains map[string]*verifiedChain
+	ids    []string
+	issues []ChainIssue
+	events int
+}
+
+type verifiedChain struct {
+	start   chainHead
+	links   map[uint64]Integrity
+	eventID map[uint64]string
+	maxSeq  uint64
+}
+
+func NewChainVerifier() *ChainVerifier {
+	return &ChainVerifier{chains: make(map[string]*verifiedChain)}
+}
+
+// Resume seeds a chain with a head that was verified previously, so that a
+// stream starting mid-chain is not reported as a gap from the first sequence.
+func (v *ChainVerifier) Resume(chainID string, sequence uint64, hash string) {
+	v.chain(chainID).start = chainHead{sequence: sequence, hash: hash}
+}
+
+func (v *ChainVerifier) Add(event Event) {
+	v.events++
+
+	if event.Integrity == nil {
+		v.issues = append(v.issues, ChainIssue{
+			Kind:    ChainIssueUnchained,
+			EventID: event.ID,
+			Detail:  "event carries no integrity block",
+		})
+		return
+	}
+
+	integrity := *event.Integrity
+	chain := v.chain(integrity.ChainID)
+
+	if hash, err := EventHash(event); err != nil || hash != integrity.Hash {
+		v.issues = append(v.issues, ChainIssue{
+			Kind:     ChainIssueModified,
+			ChainID:  integrity.ChainID,
+			EventID:  event.ID,
+			Sequence: integrity.Sequence,
+			Detail:   "hash does not match event contents",
+		})
+	}
+
+	if _, seen := chain.links[integrity.Sequence]; seen {
+		v.issues = append(v.issues, ChainIssue{
+			Kind:     ChainIssueDuplicate,
+			ChainID:  integrity.ChainID,
+			EventID:  event.ID,
+			Sequence: integrity.Sequence,
+			Detail:   fmt.Sprintf("sequence already seen for event %s", chain.eventID[integrity.Sequence]),
+		})
+		return
+	}
+
+	if integrity.Sequence < chain.maxSeq {
+		v.issues = append(v.issues, ChainIssue{
+			Kind:     ChainIssueReordered,
+			ChainID:  integrity.ChainID,
+			EventID:  event.ID,
+			Sequence: integrity.Sequence,
+			Detail:   fmt.Sprintf("arrived after sequence %d", chain.maxSeq),
+		})
+	} else {
+		chain.maxSeq = integrity.Sequence
+	}
+
+	chain.links[integrity.Sequence] = integrity
+	chain.eventID[integrity.Sequence] = event.ID
+}
+
+func (v *ChainVerifier) Report() ChainReport {
+	report := ChainReport{
+		Chains: len(v.ids),
+		Events: v.events,
+		Issues: append([]ChainIssue(nil), v.issues...),
+	}
+
+	for _, id := range v.ids {
+		report.Issues = append(report.Issues, v.chains[id].linkIssues(id)...)
+	}
+
+	return report
+}
+
+func (v *ChainVerifier) chain(id string) *verifiedChain {
+	chain, ok := v.chains[id]
+	if !ok {
+		chain = &verifiedChain{
+			links:   make(map[uint64]Integrity),
+			eventID: make(map[uint64]string),
+		}
+		v.chains[id] = chain
+		v.ids = append(v.ids, id)
+	}
+	return chain
+}
+
+func (c *verifiedChain) linkIssues(chainID string) []ChainIssue {
+	sequences := make([]uint64, 0, len(c.links))
+	for seq := range c.links {
+		sequences = append(sequences, seq)
+	}
+	sort.Slice(sequences, func(i, j int) bool { return sequences[i] < sequences[j] })
+
+	var issues []ChainIssue
+	prev := c.start
+
+	for _, seq := range sequences {
+		link := c.links[seq]
+
+		if seq <= c.start.sequence {
+			continue
+		}
+
+		if seq > prev.sequence+1 {
+			issues = append(issues, ChainIssue{
+				Kind:     ChainIssueGap,
+				ChainID:  chainID,
+				EventID:  c.eventID[seq],
+				Sequence: prev.sequence + 1,
+				Detail:   fmt.Sprintf("sequences %d-%d missing", prev.sequence+1, seq-1),
+			})
+		} else if link.PrevHash != prev.hash {
+			issues = append(issues, ChainIssue{
+				Kind:     ChainIssueBrokenLink,
+				ChainID:  chainID,
+				EventID:  c.eventID[seq],
+				Sequence: seq,
+				Detail:   "prev_hash does not match the preceding event",
+			})
+		}
+
+		prev = chainHead{sequence: seq, hash: link.Hash}
+	}
+
+	return issues
+}
+
+func VerifyChain(events []Event) ChainReport {
+	verifier := NewChainVerifier()
+	for _, event := range events {
+		verifier.Add(event)
+	}
+	return verifier.Report()
+}
@@ -0,0 +1,247 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func emittedEvents(t *testing.T, mock *mockProducer) []Event {
+	t.Helper()
+
+	events := make([]Event, 0, len(mock.producedMessages))
+	for _, msg := range mock.producedMessages {
+		var event Event
+		require.NoError(t, json.Unmarshal(msg.value, &event))
+		events = append(events, event)
+	}
+	return events
+}
+
+func emitN(t *testing.T, c *client, teamID string, n int) {
+	t.Helper()
+
+	for i := 0; i < n; i++ {
+		require.NoError(t, c.Emit(Event{
+			TeamID:   teamID,
+			Event:    EventInfo{Type: "test.event"},
+			Metadata: &Metadata{"index": i},
+		}))
+	}
+}
+
+func TestHashChain_LinksEvents(t *testing.T) {
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{HashChain: &HashChainConfig{Enable: true, ProducerID: "producer-a"}})
+
+	emitN(t, c, "team-1", 3)
+
+	events := emittedEvents(t, mock)
+	require.Len(t, events, 3)
+
+	for i, event := range events {
+		require.NotNil(t, event.Integrity)
+		assert.Equal(t, "producer-a", event.Integrity.ChainID)
+		assert.Equal(t, "producer-a", event.Integrity.ProducerID)
+		assert.Equal(t, uint64(i+1), event.Integrity.Sequence)
+		assert.Len(t, event.Integrity.Hash, 64)
+	}
+
+	assert.Empty(t, events[0].Integrity.PrevHash)
+	assert.Equal(t, events[0].Integrity.Hash, events[1].Integrity.PrevHash)
+	assert.Equal(t, events[1].Integrity.Hash, events[2].Integrity.PrevHash)
+
+	report := VerifyChain(events)
+	assert.True(t, report.OK(), "%+v", report.Issues)
+	assert.Equal(t, 1, report.Chains)
+	assert.Equal(t, 3, report.Events)
+}
+
+func TestHashChain_PerTeam(t *testing.T) {
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{HashChain: &HashChainConfig{Enable: true, ProducerID: "producer-a", PerTeam: true}})
+
+	emitN(t, c, "team-1", 2)
+	emitN(t, c, "team-2", 1)
+
+	events := emittedEvents(t, mock)
+	require.Len(t, events, 3)
+
+	assert.Equal(t, "producer-a/team-1", events[0].Integrity.ChainID)
+	assert.Equal(t, uint64(2), events[1].Integrity.Sequence)
+	assert.Equal(t, "producer-a/team-2", events[2].Integrity.ChainID)
+	assert.Equal(t, uint64(1), events[2].Integrity.Sequence)
+
+	report := VerifyChain(events)
+	assert.True(t, report.OK(), "%+v", report.Issues)
+	assert.Equal(t, 2, report.Chains)
+}
+
+func TestHashChain_FailedEmitDoesNotAdvance(t *testing.T) {
+	chain := newHashChain(&HashChainConfig{ProducerID: "producer-a"})
+
+	event := Event{TeamID: "team-1", Event: EventInfo{Type: "test.event"}}
+	err := chain.seal(&event, func() error { return errors.New("boom") })
+	require.Error(t, err)
+
+	event = Event{TeamID: "team-1", Event: EventInfo{Type: "test.event"}}
+	require.NoError(t, chain.seal(&event, func() error { return nil }))
+	assert.Equal(t, uint64(1), event.Integrity.Sequence)
+}
+
+func TestHashChain_ChainsDoNotBlockEachOther(t *testing.T) {
+	chain := newHashChain(&HashChainConfig{ProducerID: "producer-a", PerTeam: true})
+
+	sealing := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		event := Event{TeamID: "team-1", Event: EventInfo{Type: "test.event"}}
+		done <- chain.seal(&event, func() error {
+			close(sealing)
+			<-release
+			return nil
+		})
+	}()
+	<-sealing
+
+	event := Event{TeamID: "team-2", Event: EventInfo{Type: "test.event"}}
+	require.NoError(t, chain.seal(&event, func() error { return nil }))
+	assert.Equal(t, uint64(1), event.Integrity.Sequence)
+
+	close(release)
+	require.NoError(t, <-done)
+}
+
+func TestVerifyChain_MultipleProducers(t *testing.T) {
+	mockA, mockB := &mockProducer{}, &mockProducer{}
+	a := newTestClient(mockA, &Config{HashChain: &HashChainConfig{Enable: true, ProducerID: "producer-a"}})
+	b := newTestClient(mockB, &Config{HashChain: &HashChainConfig{Enable: true, ProducerID: "producer-b"}})
+
+	emitN(t, a, "team-1", 2)
+	emitN(t, b, "team-1", 2)
+
+	eventsA, eventsB := emittedEvents(t, mockA), emittedEvents(t, mockB)
+	interleaved := []Event{eventsA[0], eventsB[0], eventsB[1], eventsA[1]}
+
+	report := VerifyChain(interleaved)
+	assert.True(t, report.OK(), "%+v", report.Issues)
+	assert.Equal(t, 2, report.Chains)
+}
+
+func TestVerifyChain_DetectsGap(t *testing.T) {
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{HashChain: &HashChainConfig{Enable: true, ProducerID: "producer-a"}})
+	emitN(t, c, "team-1", 5)
+
+	events := emittedEvents(t, mock)
+	report := VerifyChain([]Event{events[0], events[1], events[4]})
+
+	require.Len(t, report.Issues, 1)
+	assert.Equal(t, ChainIssueGap, report.Issues[0].Kind)
+	assert.Equal(t, uint64(3), report.Issues[0].Sequence)
+	assert.Equal(t, events[4].ID, report.Issues[0].EventID)
+}
+
+func TestVerifyChain_DetectsMissingGenesis(t *testing.T) {
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{HashChain: &HashChainConfig{Enable: true, ProducerID: "producer-a"}})
+	emitN(t, c, "team-1", 3)
+
+	events := emittedEvents(t, mock)
+	report := VerifyChain(events[1:])
+
+	require.Len(t, report.Issues, 1)
+	assert.Equal(t, ChainIssueGap, report.Issues[0].Kind)
+	assert.Equal(t, uint64(1), report.Issues[0].Sequence)
+}
+
+func TestChainVerifier_Resume(t *testing.T) {
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{HashChain: &HashChainConfig{Enable: true, ProducerID: "producer-a"}})
+	emitN(t, c, "team-1", 3)
+
+	events := emittedEvents(t, mock)
+
+	verifier := NewChainVerifier()
+	verifier.Resume("producer-a", 1, events[0].Integrity.Hash)
+	verifier.Add(events[1])
+	verifier.Add(events[2])
+
+	report := verifier.Report()
+	assert.True(t, report.OK(), "%+v", report.Issues)
+}
+
+func TestVerifyChain_DetectsReordering(t *testing.T) {
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{HashChain: &HashChainConfig{Enable: true, ProducerID: "producer-a"}})
+	emitN(t, c, "team-1", 3)
+
+	events := emittedEvents(t, mock)
+	report := VerifyChain([]Event{events[0], events[2], events[1]})
+
+	require.Len(t, report.Issues, 1)
+	assert.Equal(t, ChainIssueReordered, report.Issues[0].Kind)
+	assert.Equal(t, events[1].ID, report.Issues[0].EventID)
+}
+
+func TestVerifyChain_DetectsModification(t *testing.T) {
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{HashChain: &HashChainConfig{Enable: true, ProducerID: "producer-a"}})
+	emitN(t, c, "team-1", 3)
+
+	events := emittedEvents(t, mock)
+	events[1].Event.Description = "tampered"
+
+	report := VerifyChain(events)
+
+	require.Len(t, report.Issues, 1)
+	assert.Equal(t, ChainIssueModified, report.Issues[0].Kind)
+	assert.Equal(t, uint64(2), report.Issues[0].Sequence)
+}
+
+func TestVerifyChain_DetectsReplacedEvent(t *testing.T) {
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{HashChain: &HashChainConfig{Enable: true, ProducerID: "producer-a"}})
+	emitN(t, c, "team-1", 3)
+
+	events := emittedEvents(t, mock)
+
+	forged := events[1]
+	forged.Event.Description = "forged"
+	forged.Integrity = &Integrity{
+		ChainID:    forged.Integrity.ChainID,
+		ProducerID: forged.Integrity.ProducerID,
+		Sequence:   forged.Integrity.Sequence,
+	}
+	hash, err := EventHash(forged)
+	require.NoError(t, err)
+	forged.Integrity.Hash = hash
+
+	report := VerifyChain([]Event{events[0], forged, events[2]})
+
+	kinds := make([]ChainIssueKind, 0, len(report.Issues))
+	for _, issue := range report.Issues {
+		kinds = append(kinds, issue.Kind)
+	}
+	assert.ElementsMatch(t, []ChainIssueKind{ChainIssueBrokenLink, ChainIssueBrokenLink}, kinds)
+}
+
+func TestVerifyChain_DetectsDuplicatesAndUnchained(t *testing.T) {
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{HashChain: &HashChainConfig{Enable: true, ProducerID: "producer-a"}})
+	emitN(t, c, "team-1", 2)
+
+	events := emittedEvents(t, mock)
+	unchained := Event{ID: "plain", TeamID: "team-1"}
+
+	report := VerifyChain([]Event{events[0], events[1], events[1], unchained})
+
+	require.Len(t, report.Issues, 2)
+	assert.Equal(t, ChainIssueDuplicate, report.Issues[0].Kind)
+	assert.Equal(t, ChainIssueUnchained, report.Issues[1].Kind)
+	assert.Equal(t, "plain", report.Issues[1].EventID)
+}
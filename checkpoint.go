@@ -0,0 +1,232 @@
+package audit
+
+import (
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const CheckpointEventType = "audit.checkpoint"
+
+type Checkpoint struct {
+	Version     string     `json:"version"`
+	ID          string     `json:"id"`
+	Type        string     `json:"type"`
+	TeamID      string     `json:"team_id"`
+	ProducerID  string     `json:"producer_id"`
+	WindowStart Timestamp  `json:"window_start"`
+	WindowEnd   Timestamp  `json:"window_end"`
+	Count       int        `json:"count"`
+	Root        string     `json:"root"`
+	Signature   *Signature `json:"signature,omitempty"`
+}
+
+func CheckpointPayload(cp Checkpoint) ([]byte, error) {
+	cp.Signature = nil
+	return canonicalJSON(cp)
+}
+
+type checkpointer struct {
+	producerID string
+	window     time.Duration
+	topic      string
+	signer     Signer
+	producer   Producer
+	logger     *slog.Logger
+
+	mu    sync.Mutex
+	teams map[string]*teamBatch
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// teamBatch holds the open batch of one team. Its lock is held while an event
+// is emitted, so only events of the same team wait on each other.
+type teamBatch struct {
+	mu      sync.Mutex
+	current *batch
+}
+
+type batch struct {
+	id     string
+	teamID string
+	start  time.Time
+	ids    []string
+	leaves [][]byte
+}
+
+func newCheckpointer(cfg *CheckpointConfig, producer Producer, logger *slog.Logger) *checkpointer {
+	return &checkpointer{
+		producerID: cfg.ProducerID,
+		window:     cfg.Window,
+		topic:      cfg.Topic,
+		signer:     cfg.Signer,
+		producer:   producer,
+		logger:     logger,
+		teams:      make(map[string]*teamBatch),
+	}
+}
+
+func (c *checkpointer) start() {
+	c.stop = make(chan struct{})
+	c.done = make(chan struct{})
+
+	go func() {
+		defer close(c.done)
+
+		ticker := time.NewTicker(c.window)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				c.flush(time.Now().UTC(), false)
+			case <-c.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (c *checkpointer) close() {
+	if c.stop != nil {
+		close(c.stop)
+		<-c.done
+	}
+	c.flush(time.Now().UTC(), true)
+}
+
+// record assigns the event its position in the current batch of its team and
+// runs emit while the batch is locked, so leaf indexes match emission order.
+// A new batch is only stored once its first event has reached a topic.
+func (c *checkpointer) record(event *Event, emit func() error) error {
+	team := c.team(event.TeamID)
+
+	team.mu.Lock()
+	defer team.mu.Unlock()
+
+	b := team.current
+	if b == nil {
+		b = &batch{
+			id:     uuid.New().String(),
+			teamID: event.TeamID,
+			start:  time.Now().UTC(),
+		}
+	}
+
+	event.Batch = &BatchRef{ID: b.id, Index: len(b.leaves)}
+
+	emitErr := emit()
+	if emitErr != nil && !partiallyPublished(emitErr) {
+		return emitErr
+	}
+
+	leaf, err := merkleLeaf(*event)
+	if err != nil {
+		return err
+	}
+
+	b.ids = append(b.ids, event.ID)
+	b.leaves = append(b.leaves, leaf)
+	team.current = b
+	return emitErr
+}
+
+func (c *checkpointer) team(teamID string) *teamBatch {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	team, ok := c.teams[teamID]
+	if !ok {
+		team = &teamBatch{}
+		c.teams[teamID] = team
+	}
+	return team
+}
+
+func (c *checkpointer) flush(now time.Time, all bool) {
+	c.mu.Lock()
+	teams := make([]*teamBatch, 0, len(c.teams))
+	for _, team := range c.teams {
+		teams = append(teams, team)
+	}
+	c.mu.Unlock()
+
+	var closed []*batch
+	for _, team := range teams {
+		team.mu.Lock()
+		if b := team.current; b != nil && (all || !now.Before(b.start.Add(c.window))) {
+			if len(b.leaves) > 0 {
+				closed = append(closed, b)
+			}
+			team.current = nil
+		}
+		team.mu.Unlock()
+	}
+
+	for _, b := range closed {
+		if err := c.emit(b, now); err != nil {
+			c.logger.Error("failed to emit audit checkpoint",
+				slog.String("batch_id", b.id),
+				slog.String("team_id", b.teamID),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+}
+
+// checkpointReport handles a report for the checkpoint topic, and reports
+// whether it was one. Checkpoints are not events, so a failed one is logged
+// instead of being passed to OnError or dead-lettered.
+func (c *client) checkpointReport(topic string, key []byte, err error) bool {
+	if c.checkpoints == nil || topic != c.checkpoints.topic {
+		return false
+	}
+	if err != nil {
+		c.logger.Error("failed to deliver audit checkpoint",
+			slog.String("team_id", string(key)),
+			slog.String("topic", topic),
+			slog.String("error", err.Error()),
+		)
+	}
+	return true
+}
+
+func (c *checkpointer) emit(b *batch, end time.Time) error {
+	tree := newMerkleTree(b.id, b.ids, b.leaves)
+
+	cp := Checkpoint{
+		Version:     Version,
+		ID:          b.id,
+		Type:        CheckpointEventType,
+		TeamID:      b.teamID,
+		ProducerID:  c.producerID,
+		WindowStart: Timestamp{Time: b.start},
+		WindowEnd:   Timestamp{Time: end},
+		Count:       tree.Count(),
+		Root:        tree.Root(),
+	}
+
+	payload, err := CheckpointPayload(cp)
+	if err != nil {
+		return err
+	}
+
+	sig, err := signPayload(c.signer, payload)
+	if err != nil {
+		return err
+	}
+	cp.Signature = sig
+
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+
+	c.producer.ProduceAsync([]string{c.topic}, []byte(b.teamID), data)
+	return nil
+}
@@ -0,0 +1,173 @@
+package audit
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/NeuralTrust/audit-sdk-go/kafka"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testCheckpointConfig(signer Signer) *CheckpointConfig {
+	return &CheckpointConfig{
+		Enable:     true,
+		Window:     time.Minute,
+		Topic:      "checkpoints",
+		ProducerID: "producer-a",
+		Signer:     signer,
+	}
+}
+
+func splitCheckpoints(t *testing.T, mock *mockProducer) ([]Event, []Checkpoint) {
+	t.Helper()
+
+	var events []Event
+	var checkpoints []Checkpoint
+	for _, msg := range mock.producedMessages {
+		if msg.topics[0] == "checkpoints" {
+			var cp Checkpoint
+			require.NoError(t, json.Unmarshal(msg.value, &cp))
+			checkpoints = append(checkpoints, cp)
+			continue
+		}
+		var event Event
+		require.NoError(t, json.Unmarshal(msg.value, &event))
+		events = append(events, event)
+	}
+	return events, checkpoints
+}
+
+func TestCheckpointer_EmitsSignedCheckpoint(t *testing.T) {
+	mock := &mockProducer{}
+	signer := newTestEd25519Signer(t, "key-1")
+	c := newTestClient(mock, &Config{Checkpoint: testCheckpointConfig(signer)})
+
+	emitN(t, c, "team-1", 5)
+	c.checkpoints.flush(time.Now().UTC(), true)
+
+	events, checkpoints := splitCheckpoints(t, mock)
+	require.Len(t, events, 5)
+	require.Len(t, checkpoints, 1)
+
+	cp := checkpoints[0]
+	assert.Equal(t, CheckpointEventType, cp.Type)
+	assert.Equal(t, "team-1", cp.TeamID)
+	assert.Equal(t, "producer-a", cp.ProducerID)
+	assert.Equal(t, 5, cp.Count)
+	assert.False(t, cp.WindowEnd.Before(cp.WindowStart.Time))
+
+	for i, event := range events {
+		require.NotNil(t, event.Batch)
+		assert.Equal(t, cp.ID, event.Batch.ID)
+		assert.Equal(t, i, event.Batch.Index)
+	}
+
+	require.NotNil(t, cp.Signature)
+	payload, err := CheckpointPayload(cp)
+	require.NoError(t, err)
+	sig, err := base64.RawURLEncoding.DecodeString(cp.Signature.Value)
+	require.NoError(t, err)
+	assert.True(t, ed25519.Verify(signer.Public().(ed25519.PublicKey), payload, sig))
+
+	tree, err := BuildMerkleTree(events)
+	require.NoError(t, err)
+	assert.Equal(t, cp.Root, tree.Root())
+
+	proof, err := tree.Prove(events[3].ID)
+	require.NoError(t, err)
+	assert.NoError(t, VerifyInclusion(events[3], proof, cp.Root))
+}
+
+func TestCheckpointer_WindowsPerTeam(t *testing.T) {
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{Checkpoint: testCheckpointConfig(newTestEd25519Signer(t, "key-1"))})
+
+	emitN(t, c, "team-1", 2)
+	emitN(t, c, "team-2", 3)
+
+	c.checkpoints.flush(time.Now().UTC(), false)
+	_, checkpoints := splitCheckpoints(t, mock)
+	assert.Empty(t, checkpoints)
+
+	c.checkpoints.flush(time.Now().UTC().Add(2*time.Minute), false)
+	_, checkpoints = splitCheckpoints(t, mock)
+	require.Len(t, checkpoints, 2)
+
+	counts := map[string]int{}
+	for _, cp := range checkpoints {
+		counts[cp.TeamID] = cp.Count
+	}
+	assert.Equal(t, map[string]int{"team-1": 2, "team-2": 3}, counts)
+
+	emitN(t, c, "team-1", 1)
+	events, _ := splitCheckpoints(t, mock)
+	last := events[len(events)-1]
+	assert.Equal(t, 0, last.Batch.Index)
+	assert.NotEqual(t, events[0].Batch.ID, last.Batch.ID)
+}
+
+func TestCheckpointer_WithHashChain(t *testing.T) {
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{
+		HashChain:  &HashChainConfig{Enable: true, ProducerID: "producer-a"},
+		Checkpoint: testCheckpointConfig(newTestEd25519Signer(t, "key-1")),
+	})
+
+	emitN(t, c, "team-1", 3)
+	c.checkpoints.flush(time.Now().UTC(), true)
+
+	events, checkpoints := splitCheckpoints(t, mock)
+	require.Len(t, checkpoints, 1)
+
+	assert.True(t, VerifyChain(events).OK())
+
+	tree, err := BuildMerkleTree(events)
+	require.NoError(t, err)
+	assert.Equal(t, checkpoints[0].Root, tree.Root())
+}
+
+func TestClient_Close_FlushesCheckpoints(t *testing.T) {
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{Checkpoint: testCheckpointConfig(newTestEd25519Signer(t, "key-1"))})
+	c.checkpoints.start()
+
+	emitN(t, c, "team-1", 1)
+	require.NoError(t, c.Close())
+
+	_, checkpoints := splitCheckpoints(t, mock)
+	assert.Len(t, checkpoints, 1)
+	assert.True(t, mock.closed)
+}
+
+func TestClient_CheckpointDeliveryFailure(t *testing.T) {
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{Checkpoint: testCheckpointConfig(nil)})
+	c.config.DeadLetter = &DeadLetterConfig{Enable: true, Topic: "dead"}
+	var reported int
+	c.config.OnError = func(Event, error) { reported++ }
+
+	data, err := CheckpointPayload(Checkpoint{ID: "batch-1", TeamID: "team-1"})
+	require.NoError(t, err)
+	c.handleDelivery(kafka.DeliveryReport{Topic: "checkpoints", Key: []byte("team-1"), Value: data, Err: errors.New("timed out"), Enqueued: true})
+	c.handleDrop(kafka.DropReport{Topic: "checkpoints", Key: []byte("team-1"), Value: data, Reason: kafka.DropQueueFull})
+
+	assert.Zero(t, reported)
+	assert.Empty(t, mock.producedMessages)
+}
+
+func TestCheckpointer_FailedFirstEmit(t *testing.T) {
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{Checkpoint: testCheckpointConfig(newTestEd25519Signer(t, "key-1"))})
+
+	event := Event{ID: "event-1", TeamID: "team-1"}
+	err := c.checkpoints.record(&event, func() error { return errors.New("sign failed") })
+	require.Error(t, err)
+
+	assert.NotPanics(t, func() { c.checkpoints.flush(time.Now().UTC(), true) })
+	assert.Empty(t, mock.producedMessages)
+}
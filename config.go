@@ -1,16 +1,29 @@
 package audit
 
 import (
+	"log/slog"
 	"os"
 	"time"
+
+	"github.com/NeuralTrust/audit-sdk-go/kafka"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
 	DefaultAuditEventsTopic     = "audit_events"
 	DefaultAuditLogsIngestTopic = "audit_logs_ingest"
 
+	DefaultCheckpointsTopic = "audit_checkpoints"
+	DefaultDeadLetterTopic  = "audit_dead_letters"
+
 	EnvAuditEventsTopic     = "AUDIT_EVENTS_TOPIC"
 	EnvAuditLogsIngestTopic = "AUDIT_LOGS_INGEST_TOPIC"
+	EnvCheckpointsTopic     = "AUDIT_CHECKPOINTS_TOPIC"
+	EnvDeadLetterTopic      = "AUDIT_DEAD_LETTER_TOPIC"
+
+	EnvServiceName    = "AUDIT_SERVICE_NAME"
+	EnvServiceVersion = "AUDIT_SERVICE_VERSION"
+	EnvEnvironment    = "AUDIT_ENVIRONMENT"
 )
 
 type LogLevel string
@@ -26,18 +39,48 @@ type Config struct {
 	Brokers              []string
 	AuditEventsTopic     string
 	AuditLogsIngestTopic string
+	Routes               []Route
 	ClientID             string
 	BatchSize            int
 	BatchTimeout         time.Duration
 	RetryMax             int
 	RetryBackoff         time.Duration
+	SyncPublish          bool
 	RequiredAcks         int
+	Idempotent           bool
+	MaxInFlight          int
+	TransactionalID      string
+	TransactionTimeout   time.Duration
 	TopicAutoCreate      bool
 	TopicNumParts        int
 	TopicReplication     int
 	TLS                  *TLSConfig
 	SASL                 *SASLConfig
 	LogLevel             LogLevel
+	Logger               *slog.Logger
+	OnError              func(Event, error)
+	Observer             Observer
+	StatsInterval        time.Duration
+	OnStats              func(Stats)
+	QueueMaxMessages     int
+	QueueFullPolicy      QueueFullPolicy
+	BlockTimeout         time.Duration
+	OverflowBuffer       int
+	SpoolDir             string
+	SpoolMaxBytes        int64
+	OnDrop               func(Event, DropReason)
+	Health               *HealthConfig
+	Interceptors         []Interceptor
+	Source               *SourceConfig
+	MaxEventSize         int
+	OversizePolicy       OversizePolicy
+	MaxTargets           int
+	TargetOverflow       TargetOverflowPolicy
+	HashChain            *HashChainConfig
+	Signer               Signer
+	Checkpoint           *CheckpointConfig
+	DeadLetter           *DeadLetterConfig
+	Tracing              *TracingConfig
 }
 
 type TLSConfig struct {
@@ -55,6 +98,45 @@ type SASLConfig struct {
 	Password  string
 }
 
+type HashChainConfig struct {
+	Enable     bool
+	ProducerID string
+	PerTeam    bool
+}
+
+type CheckpointConfig struct {
+	Enable     bool
+	Window     time.Duration
+	Topic      string
+	ProducerID string
+	Signer     Signer
+}
+
+type DeadLetterConfig struct {
+	Enable bool
+	Topic  string
+}
+
+type SourceConfig struct {
+	Enable         bool
+	ServiceName    string
+	ServiceVersion string
+	Environment    string
+}
+
+type HealthConfig struct {
+	Window             time.Duration
+	MaxFailureRate     float64
+	MaxQueueSaturation float64
+	Timeout            time.Duration
+}
+
+type TracingConfig struct {
+	Enable         bool
+	TracerProvider trace.TracerProvider
+	SpanEvents     bool
+}
+
 func (c *Config) setDefaults() {
 	c.AuditEventsTopic = resolveValue(c.AuditEventsTopic, EnvAuditEventsTopic, DefaultAuditEventsTopic)
 	c.AuditLogsIngestTopic = resolveValue(c.AuditLogsIngestTopic, EnvAuditLogsIngestTopic, DefaultAuditLogsIngestTopic)
@@ -74,12 +156,121 @@ func (c *Config) setDefaults() {
 	if c.RetryBackoff == 0 {
 		c.RetryBackoff = 100 * time.Millisecond
 	}
+	if c.TransactionalID != "" {
+		c.Idempotent = true
+	}
+	if c.Idempotent && c.RequiredAcks == 0 {
+		c.RequiredAcks = -1
+	}
+	if c.Idempotent && c.MaxInFlight == 0 {
+		c.MaxInFlight = kafka.MaxIdempotentInFlight
+	}
 	if c.RequiredAcks == 0 {
 		c.RequiredAcks = 1
 	}
 	if c.LogLevel == "" {
 		c.LogLevel = LogLevelInfo
 	}
+	if c.MaxEventSize == 0 {
+		c.MaxEventSize = DefaultMaxEventSize
+	}
+	if c.OversizePolicy == "" {
+		c.OversizePolicy = OversizeReject
+	}
+	if c.MaxTargets == 0 {
+		c.MaxTargets = DefaultMaxTargets
+	}
+	if c.TargetOverflow == "" {
+		c.TargetOverflow = TargetOverflowSummarize
+	}
+	if c.HashChain != nil && c.HashChain.Enable && c.HashChain.ProducerID == "" {
+		c.HashChain.ProducerID = defaultProducerID()
+	}
+	if c.Checkpoint != nil && c.Checkpoint.Enable {
+		c.Checkpoint.setDefaults(c)
+	}
+	if c.DeadLetter != nil && c.DeadLetter.Enable {
+		c.DeadLetter.Topic = resolveValue(c.DeadLetter.Topic, EnvDeadLetterTopic, DefaultDeadLetterTopic)
+	}
+	if c.Health == nil {
+		c.Health = &HealthConfig{}
+	}
+	c.Health.setDefaults()
+	if c.Source != nil && c.Source.Enable {
+		c.Source.ServiceName = resolveValue(c.Source.ServiceName, EnvServiceName, "")
+		c.Source.ServiceVersion = resolveValue(c.Source.ServiceVersion, EnvServiceVersion, "")
+		c.Source.Environment = resolveValue(c.Source.Environment, EnvEnvironment, "")
+	}
+}
+
+func (c *CheckpointConfig) setDefaults(cfg *Config) {
+	c.Topic = resolveValue(c.Topic, EnvCheckpointsTopic, DefaultCheckpointsTopic)
+
+	if c.Window == 0 {
+		c.Window = 1 * time.Minute
+	}
+	if c.ProducerID == "" {
+		if cfg.HashChain != nil && cfg.HashChain.ProducerID != "" {
+			c.ProducerID = cfg.HashChain.ProducerID
+		} else {
+			c.ProducerID = defaultProducerID()
+		}
+	}
+	if c.Signer == nil {
+		c.Signer = cfg.Signer
+	}
+}
+
+func (c *HealthConfig) setDefaults() {
+	if c.Window == 0 {
+		c.Window = DefaultHealthWindow
+	}
+	if c.MaxFailureRate == 0 {
+		c.MaxFailureRate = DefaultMaxFailureRate
+	}
+	if c.MaxQueueSaturation == 0 {
+		c.MaxQueueSaturation = DefaultMaxQueueSaturation
+	}
+	if c.Timeout == 0 {
+		c.Timeout = DefaultHealthTimeout
+	}
+}
+
+// auxiliaryTopics returns the enabled topics besides the two event topics.
+func (c *Config) auxiliaryTopics() []string {
+	var topics []string
+	if c.Checkpoint != nil && c.Checkpoint.Enable {
+		topics = append(topics, c.Checkpoint.Topic)
+	}
+	if c.DeadLetter != nil && c.DeadLetter.Enable {
+		topics = append(topics, c.DeadLetter.Topic)
+	}
+	return topics
+}
+
+func (c *Config) kafkaSecurity() (*kafka.TLSConfig, *kafka.SASLConfig) {
+	var tlsCfg *kafka.TLSConfig
+	if c.TLS != nil {
+		tlsCfg = &kafka.TLSConfig{
+			Enable:             c.TLS.Enable,
+			CertFile:           c.TLS.CertFile,
+			KeyFile:            c.TLS.KeyFile,
+			CAFile:             c.TLS.CAFile,
+			InsecureSkipVerify: c.TLS.InsecureSkipVerify,
+		}
+	}
+
+	var saslCfg *kafka.SASLConfig
+	if c.SASL != nil {
+		saslCfg = &kafka.SASLConfig{
+			Enable:    c.SASL.Enable,
+			Mechanism: c.SASL.Mechanism,
+			Username:  c.SASL.Username,
+			Password:  c.SASL.Password,
+		}
+	}
+
+	return tlsCfg, saslCfg
 }
 
 func resolveValue(configValue, envKey, defaultValue string) string {
@@ -96,7 +287,25 @@ func (c *Config) validate() error {
 	if len(c.Brokers) == 0 {
 		return ErrNoBrokers
 	}
+	if c.Idempotent && c.RequiredAcks != -1 {
+		return ErrIdempotenceAcks
+	}
+	if c.Idempotent && c.MaxInFlight > kafka.MaxIdempotentInFlight {
+		return ErrIdempotenceInFlight
+	}
+	if c.TransactionalID != "" && c.QueueFullPolicy != "" {
+		return ErrTransactionalQueue
+	}
+	if c.QueueFullPolicy == QueueFullSpool && c.SpoolDir == "" {
+		return ErrNoSpoolDir
+	}
+	if c.Checkpoint != nil && c.Checkpoint.Enable && c.Checkpoint.Signer == nil {
+		return ErrNoCheckpointSigner
+	}
+	for i := range c.Routes {
+		if err := c.Routes[i].validate(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
-
-
@@ -19,6 +19,8 @@ func TestConfig_SetDefaults(t *testing.T) {
 	assert.Equal(t, 3, cfg.RetryMax)
 	assert.Equal(t, 100*time.Millisecond, cfg.RetryBackoff)
 	assert.Equal(t, 1, cfg.RequiredAcks)
+	assert.Equal(t, DefaultMaxEventSize, cfg.MaxEventSize)
+	assert.Equal(t, OversizeReject, cfg.OversizePolicy)
 }
 
 func TestConfig_SetDefaults_FromEnv(t *testing.T) {
@@ -105,3 +107,78 @@ func TestResolveValue(t *testing.T) {
 		})
 	}
 }
+
+func TestConfig_SetDefaults_HashChainProducerID(t *testing.T) {
+	cfg := &Config{HashChain: &HashChainConfig{Enable: true}}
+	cfg.setDefaults()
+
+	assert.NotEmpty(t, cfg.HashChain.ProducerID)
+
+	other := &Config{HashChain: &HashChainConfig{Enable: true}}
+	other.setDefaults()
+
+	assert.NotEqual(t, cfg.HashChain.ProducerID, other.HashChain.ProducerID)
+}
+
+func TestConfig_SetDefaults_Checkpoint(t *testing.T) {
+	signer := &ed25519Signer{keyID: "key-1"}
+	cfg := &Config{
+		HashChain:  &HashChainConfig{Enable: true, ProducerID: "producer-a"},
+		Signer:     signer,
+		Checkpoint: &CheckpointConfig{Enable: true},
+	}
+	cfg.setDefaults()
+
+	assert.Equal(t, DefaultCheckpointsTopic, cfg.Checkpoint.Topic)
+	assert.Equal(t, time.Minute, cfg.Checkpoint.Window)
+	assert.Equal(t, "producer-a", cfg.Checkpoint.ProducerID)
+	assert.Equal(t, signer, cfg.Checkpoint.Signer)
+}
+
+func TestConfig_SetDefaults_DeadLetter(t *testing.T) {
+	cfg := &Config{
+		Brokers:    []string{"localhost:9092"},
+		Checkpoint: &CheckpointConfig{Enable: true},
+		DeadLetter: &DeadLetterConfig{Enable: true},
+	}
+	cfg.setDefaults()
+
+	assert.Equal(t, DefaultDeadLetterTopic, cfg.DeadLetter.Topic)
+	assert.Equal(t, []string{DefaultCheckpointsTopic, DefaultDeadLetterTopic}, cfg.auxiliaryTopics())
+}
+
+func TestConfig_SetDefaults_Idempotent(t *testing.T) {
+	cfg := &Config{Brokers: []string{"localhost:9092"}, TransactionalID: "audit-1"}
+	cfg.setDefaults()
+
+	assert.True(t, cfg.Idempotent)
+	assert.Equal(t, -1, cfg.RequiredAcks)
+	assert.Equal(t, 5, cfg.MaxInFlight)
+	assert.NoError(t, cfg.validate())
+}
+
+func TestConfig_Validate_Idempotent(t *testing.T) {
+	cfg := &Config{Brokers: []string{"localhost:9092"}, Idempotent: true, RequiredAcks: 1}
+	cfg.setDefaults()
+	assert.Equal(t, ErrIdempotenceAcks, cfg.validate())
+
+	cfg = &Config{Brokers: []string{"localhost:9092"}, Idempotent: true, MaxInFlight: 10}
+	cfg.setDefaults()
+	assert.Equal(t, ErrIdempotenceInFlight, cfg.validate())
+}
+
+func TestConfig_Validate_TransactionalQueuePolicy(t *testing.T) {
+	cfg := &Config{Brokers: []string{"localhost:9092"}, TransactionalID: "audit-1", QueueFullPolicy: QueueFullBlock}
+	cfg.setDefaults()
+	assert.Equal(t, ErrTransactionalQueue, cfg.validate())
+}
+
+func TestConfig_Validate_CheckpointRequiresSigner(t *testing.T) {
+	cfg := &Config{
+		Brokers:    []string{"localhost:9092"},
+		Checkpoint: &CheckpointConfig{Enable: true},
+	}
+	cfg.setDefaults()
+
+	assert.Equal(t, ErrNoCheckpointSigner, cfg.validate())
+}
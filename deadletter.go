@@ -0,0 +1,326 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/NeuralTrust/audit-sdk-go/kafka"
+)
+
+type DeadLetterReason string
+
+const (
+	DeadLetterValidation DeadLetterReason = "validation"
+	DeadLetterTooLarge   DeadLetterReason = "too_large"
+	DeadLetterEncode     DeadLetterReason = "encode"
+	DeadLetterProduce    DeadLetterReason = "produce"
+	DeadLetterDelivery   DeadLetterReason = "delivery"
+)
+
+// DeadLetter wraps an event that could not be delivered. Payload holds the
+// event as JSON; it is empty when the event could not be encoded at all.
+type DeadLetter struct {
+	Reason    DeadLetterReason `json:"reason"`
+	Error     string           `json:"error"`
+	Topic     string           `json:"topic,omitempty"`
+	EventID   string           `json:"event_id,omitempty"`
+	TeamID    string           `json:"team_id,omitempty"`
+	Attempts  int              `json:"attempts"`
+	EmittedAt Timestamp        `json:"emitted_at"`
+	FailedAt  Timestamp        `json:"failed_at"`
+	Payload   json.RawMessage  `json:"payload,omitempty"`
+}
+
+func (c *client) deadLetterEvent(event *Event, reason DeadLetterReason, err error) {
+	if c.config.DeadLetter == nil || !c.config.DeadLetter.Enable {
+		return
+	}
+
+	payload, encodeErr := json.Marshal(event)
+	if encodeErr != nil {
+		payload = nil
+	}
+
+	now := time.Now().UTC()
+	c.deadLetter(DeadLetter{
+		Reason:    reason,
+		Error:     err.Error(),
+		EventID:   event.ID,
+		TeamID:    event.TeamID,
+		Attempts:  1,
+		EmittedAt: Timestamp{Time: now},
+		FailedAt:  Timestamp{Time: now},
+		Payload:   payload,
+	})
+}
+
+func (c *client) deadLetterReport(event Event, report kafka.DeliveryReport) {
+	if c.config.DeadLetter == nil || !c.config.DeadLetter.Enable || report.Topic == c.config.DeadLetter.Topic {
+		return
+	}
+
+	letter := DeadLetter{
+		Reason:   DeadLetterProduce,
+		Error:    report.Err.Error(),
+		Topic:    report.Topic,
+		EventID:  event.ID,
+		TeamID:   event.TeamID,
+		Attempts: 1,
+		FailedAt: Timestamp{Time: time.Now().UTC()},
+	}
+	if json.Valid(report.Value) {
+		letter.Payload = report.Value
+	}
+	if report.Enqueued {
+		// librdkafka gives up only after exhausting its retries.
+		letter.Reason = DeadLetterDelivery
+		letter.Attempts = c.config.RetryMax + 1
+	}
+	letter.EmittedAt = Timestamp{Time: letter.FailedAt.Add(-report.Latency)}
+
+	c.deadLetter(letter)
+}
+
+func (c *client) deadLetterPublish(event *Event, data []byte, result TopicResult, reason DeadLetterReason, emittedAt time.Time) {
+	if c.config.DeadLetter == nil || !c.config.DeadLetter.Enable {
+		return
+	}
+
+	c.deadLetter(DeadLetter{
+		Reason:    reason,
+		Error:     result.Err.Error(),
+		Topic:     result.Topic,
+		EventID:   event.ID,
+		TeamID:    event.TeamID,
+		Attempts:  result.Attempts,
+		EmittedAt: Timestamp{Time: emittedAt.UTC()},
+		FailedAt:  Timestamp{Time: time.Now().UTC()},
+		Payload:   data,
+	})
+}
+
+func (c *client) deadLetter(letter DeadLetter) {
+	data, err := json.Marshal(letter)
+	if err != nil {
+		c.logger.Error("failed to encode dead letter",
+			slog.String("event_id", letter.EventID),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+
+	if c.deadLetters != nil {
+		c.deadLetters.write(letter, data)
+		return
+	}
+	c.producer.ProduceAsync([]string{c.config.DeadLetter.Topic}, []byte(letter.TeamID), data)
+}
+
+const deadLetterBuffer = 1000
+
+// deadLetterWriter produces dead letters from a goroutine of its own. Most
+// letters come from delivery reports, which run on the producer's event
+// loop; producing there could wait for queue space, for example with the
+// block queue-full policy, and stall every other report meanwhile. Letters
+// that find the buffer full are logged and dropped.
+type deadLetterWriter struct {
+	producer Producer
+	topic    string
+	logger   *slog.Logger
+
+	mu      sync.RWMutex
+	closed  bool
+	letters chan deadLetterMessage
+	done    chan struct{}
+}
+
+type deadLetterMessage struct {
+	key   []byte
+	value []byte
+}
+
+func newDeadLetterWriter(producer Producer, topic string, logger *slog.Logger, size int) *deadLetterWriter {
+	w := &deadLetterWriter{
+		producer: producer,
+		topic:    topic,
+		logger:   logger,
+		letters:  make(chan deadLetterMessage, size),
+		done:     make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+func (w *deadLetterWriter) run() {
+	defer close(w.done)
+	for msg := range w.letters {
+		w.producer.ProduceAsync([]string{w.topic}, msg.key, msg.value)
+	}
+}
+
+func (w *deadLetterWriter) write(letter DeadLetter, data []byte) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if !w.closed {
+		select {
+		case w.letters <- deadLetterMessage{key: []byte(letter.TeamID), value: data}:
+			return
+		default:
+		}
+	}
+
+	w.logger.Error("dropped dead letter",
+		slog.String("event_id", letter.EventID),
+		slog.String("reason", string(letter.Reason)),
+		slog.String("error", letter.Error),
+	)
+}
+
+// close produces the buffered letters and stops the writer. Letters written
+// afterwards are dropped.
+func (w *deadLetterWriter) close() {
+	if w == nil {
+		return
+	}
+
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return
+	}
+	w.closed = true
+	close(w.letters)
+	w.mu.Unlock()
+
+	<-w.done
+}
+
+// DeadLetterReader reads raw dead-letter messages. ReadMessage returns
+// io.EOF once the topic has been drained. Commit marks the last message read,
+// and every message before it, as done.
+type DeadLetterReader interface {
+	ReadMessage(ctx context.Context) ([]byte, error)
+	Commit() error
+}
+
+// ReplayFunc inspects and fixes an event before it is re-emitted. Returning
+// ErrSkipReplay leaves the event out; any other error stops the replay.
+type ReplayFunc func(letter *DeadLetter, event *Event) error
+
+var ErrSkipReplay = errors.New("audit: skip replay")
+
+type ReplayResult struct {
+	Read     int
+	Replayed int
+	Skipped  int
+	Failed   int
+}
+
+// Replay reads dead letters from reader until it is drained and re-emits
+// their events through client. Letters without a decodable payload and
+// events the client rejects again are counted as failed.
+//
+// A letter is committed once it has been replayed or skipped. Offsets are
+// cumulative, so after the first failed letter nothing more is committed:
+// the failed letter, and those after it, are read again by the next run.
+// An error from fix stops the replay without committing its letter.
+func Replay(ctx context.Context, client Client, reader DeadLetterReader, fix ReplayFunc) (ReplayResult, error) {
+	var result ReplayResult
+	emit := func(event Event) error {
+		if e, ok := client.(ContextEmitter); ok {
+			return e.EmitContext(ctx, event)
+		}
+		return client.Emit(event)
+	}
+	committing := true
+	commit := func() error {
+		if !committing {
+			return nil
+		}
+		return reader.Commit()
+	}
+
+	for {
+		data, err := reader.ReadMessage(ctx)
+		if errors.Is(err, io.EOF) {
+			return result, nil
+		}
+		if err != nil {
+			return result, err
+		}
+		result.Read++
+
+		var letter DeadLetter
+		var event Event
+		if err := json.Unmarshal(data, &letter); err != nil || len(letter.Payload) == 0 {
+			result.Failed++
+			committing = false
+			continue
+		}
+		if err := json.Unmarshal(letter.Payload, &event); err != nil {
+			result.Failed++
+			committing = false
+			continue
+		}
+
+		if fix != nil {
+			if err := fix(&letter, &event); errors.Is(err, ErrSkipReplay) {
+				result.Skipped++
+				if err := commit(); err != nil {
+					return result, err
+				}
+				continue
+			} else if err != nil {
+				return result, err
+			}
+		}
+
+		// The blocks added when the event was first emitted no longer
+		// hold; they are added afresh by this emit.
+		event.Integrity, event.Signature, event.Batch, event.Truncation = nil, nil, nil, nil
+		if err := emit(event); err != nil {
+			result.Failed++
+			committing = false
+			continue
+		}
+		result.Replayed++
+		if err := commit(); err != nil {
+			return result, err
+		}
+	}
+}
+
+// NewDeadLetterConsumer returns a reader for the dead-letter topic configured
+// in cfg, connecting with the same brokers and credentials as the client.
+func NewDeadLetterConsumer(cfg *Config, groupID string) (*kafka.Consumer, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	cfg.setDefaults()
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	var topic string
+	if cfg.DeadLetter != nil {
+		topic = cfg.DeadLetter.Topic
+	}
+	topic = resolveValue(topic, EnvDeadLetterTopic, DefaultDeadLetterTopic)
+
+	consumerCfg := &kafka.ConsumerConfig{
+		Brokers:  cfg.Brokers,
+		ClientID: cfg.ClientID,
+		GroupID:  groupID,
+		Topic:    topic,
+	}
+	consumerCfg.TLS, consumerCfg.SASL = cfg.kafkaSecurity()
+
+	return kafka.NewConsumer(consumerCfg)
+}
@@ -0,0 +1,264 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NeuralTrust/audit-sdk-go/kafka"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func deadLetters(t *testing.T, mock *mockProducer) []DeadLetter {
+	t.Helper()
+
+	var letters []DeadLetter
+	for _, msg := range mock.producedMessages {
+		if msg.topics[0] != "dead" {
+			continue
+		}
+		var letter DeadLetter
+		require.NoError(t, json.Unmarshal(msg.value, &letter))
+		letters = append(letters, letter)
+	}
+	return letters
+}
+
+func TestClient_DeadLetter_Validation(t *testing.T) {
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{RetryMax: 3, DeadLetter: &DeadLetterConfig{Enable: true, Topic: "dead"}})
+
+	err := c.Emit(Event{Event: EventInfo{Type: "test.event"}})
+	require.ErrorIs(t, err, ErrEmptyTeamID)
+
+	letters := deadLetters(t, mock)
+	require.Len(t, letters, 1)
+	assert.Equal(t, DeadLetterValidation, letters[0].Reason)
+	assert.Equal(t, ErrEmptyTeamID.Error(), letters[0].Error)
+	assert.Equal(t, 1, letters[0].Attempts)
+	assert.False(t, letters[0].FailedAt.IsZero())
+
+	var event Event
+	require.NoError(t, json.Unmarshal(letters[0].Payload, &event))
+	assert.Equal(t, "test.event", event.Event.Type)
+}
+
+func TestClient_DeadLetter_TooLarge(t *testing.T) {
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{RetryMax: 3, DeadLetter: &DeadLetterConfig{Enable: true, Topic: "dead"}})
+	c.config.MaxEventSize = 100
+
+	err := c.Emit(Event{TeamID: "team-1", Event: EventInfo{Type: "test.event", Description: string(make([]byte, 200))}})
+	require.ErrorIs(t, err, ErrEventTooLarge)
+
+	letters := deadLetters(t, mock)
+	require.Len(t, letters, 1)
+	assert.Equal(t, DeadLetterTooLarge, letters[0].Reason)
+	assert.Equal(t, "team-1", letters[0].TeamID)
+	assert.NotEmpty(t, letters[0].EventID)
+}
+
+func TestClient_DeadLetter_Delivery(t *testing.T) {
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{RetryMax: 3, DeadLetter: &DeadLetterConfig{Enable: true, Topic: "dead"}})
+
+	value, err := json.Marshal(Event{ID: "evt-1", TeamID: "team-1", Event: EventInfo{Type: "test.event"}})
+	require.NoError(t, err)
+
+	c.handleDelivery(kafka.DeliveryReport{Topic: "events", Value: value, Enqueued: true, Latency: time.Minute, Err: errors.New("Message timed out")})
+	c.handleDelivery(kafka.DeliveryReport{Topic: "events", Value: value, Err: errors.New("Unknown topic")})
+	c.handleDelivery(kafka.DeliveryReport{Topic: "dead", Value: value, Enqueued: true, Err: errors.New("Message timed out")})
+
+	letters := deadLetters(t, mock)
+	require.Len(t, letters, 2)
+
+	assert.Equal(t, DeadLetterDelivery, letters[0].Reason)
+	assert.Equal(t, "events", letters[0].Topic)
+	assert.Equal(t, "evt-1", letters[0].EventID)
+	assert.Equal(t, 4, letters[0].Attempts)
+	assert.WithinDuration(t, letters[0].FailedAt.Add(-time.Minute), letters[0].EmittedAt.Time, time.Millisecond)
+	assert.JSONEq(t, string(value), string(letters[0].Payload))
+
+	assert.Equal(t, DeadLetterProduce, letters[1].Reason)
+	assert.Equal(t, 1, letters[1].Attempts)
+}
+
+func TestClient_DeadLetter_Disabled(t *testing.T) {
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{RetryMax: 3})
+
+	assert.Error(t, c.Emit(Event{Event: EventInfo{Type: "test.event"}}))
+	assert.Empty(t, mock.producedMessages)
+}
+
+// sliceReader records, as committed, how many messages had been read at
+// the last Commit.
+type sliceReader struct {
+	messages  [][]byte
+	read      int
+	committed int
+}
+
+func (r *sliceReader) ReadMessage(ctx context.Context) ([]byte, error) {
+	if r.read == len(r.messages) {
+		return nil, io.EOF
+	}
+	r.read++
+	return r.messages[r.read-1], nil
+}
+
+func (r *sliceReader) Commit() error {
+	r.committed = r.read
+	return nil
+}
+
+// blockedProducer waits for release before producing, like a full queue
+// under the block policy.
+type blockedProducer struct {
+	mockProducer
+	mu      sync.Mutex
+	release chan struct{}
+}
+
+func (p *blockedProducer) ProduceAsync(topics []string, key, value []byte) {
+	<-p.release
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.mockProducer.ProduceAsync(topics, key, value)
+}
+
+func TestDeadLetterWriter_DoesNotBlock(t *testing.T) {
+	producer := &blockedProducer{release: make(chan struct{})}
+	c := newTestClient(producer, &Config{RetryMax: 3, DeadLetter: &DeadLetterConfig{Enable: true, Topic: "dead"}})
+	c.deadLetters = newDeadLetterWriter(producer, "dead", testLogger(), 1)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 3; i++ {
+			c.handleDelivery(kafka.DeliveryReport{
+				Topic:    "events",
+				Value:    []byte(`{"id":"event-1","team_id":"team-1"}`),
+				Err:      errors.New("Local: Message timed out"),
+				Enqueued: true,
+			})
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("delivery reports blocked on the dead-letter produce")
+	}
+
+	close(producer.release)
+	c.deadLetters.close()
+
+	letters := deadLetters(t, &producer.mockProducer)
+	assert.GreaterOrEqual(t, len(letters), 1)
+	assert.Less(t, len(letters), 3, "letters beyond the buffer are dropped")
+}
+
+func TestReplay(t *testing.T) {
+	letter := func(event Event) []byte {
+		payload, err := json.Marshal(event)
+		require.NoError(t, err)
+		data, err := json.Marshal(DeadLetter{Reason: DeadLetterValidation, Payload: payload})
+		require.NoError(t, err)
+		return data
+	}
+
+	reader := &sliceReader{messages: [][]byte{
+		letter(Event{ID: "evt-1", Event: EventInfo{Type: "test.event"}}),
+		letter(Event{ID: "evt-2", Event: EventInfo{Type: "skip.me"}}),
+		[]byte("not json"),
+		letter(Event{ID: "evt-3", TeamID: "team-1"}),
+	}}
+
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{})
+
+	result, err := Replay(context.Background(), c, reader, func(letter *DeadLetter, event *Event) error {
+		if event.Event.Type == "skip.me" {
+			return ErrSkipReplay
+		}
+		if event.TeamID == "" {
+			event.TeamID = "team-fixed"
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, ReplayResult{Read: 4, Replayed: 1, Skipped: 1, Failed: 2}, result)
+	require.Len(t, mock.producedMessages, 1)
+	assert.Equal(t, "team-fixed", string(mock.producedMessages[0].key))
+	assert.Equal(t, 2, reader.committed, "nothing after the undecodable letter is committed")
+}
+
+func TestReplay_FailedEmitNotCommitted(t *testing.T) {
+	letter := func(event Event) []byte {
+		payload, _ := json.Marshal(event)
+		data, _ := json.Marshal(DeadLetter{Payload: payload})
+		return data
+	}
+	reader := &sliceReader{messages: [][]byte{
+		letter(Event{ID: "evt-1", TeamID: "team-1", Event: EventInfo{Type: "test.event"}}),
+		letter(Event{ID: "evt-2", Event: EventInfo{Type: "test.event"}}),
+		letter(Event{ID: "evt-3", TeamID: "team-1", Event: EventInfo{Type: "test.event"}}),
+	}}
+
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{})
+
+	result, err := Replay(context.Background(), c, reader, nil)
+	require.NoError(t, err)
+	assert.Equal(t, ReplayResult{Read: 3, Replayed: 2, Failed: 1}, result)
+	assert.Equal(t, 1, reader.committed)
+}
+
+func TestReplay_ClearsSealBlocks(t *testing.T) {
+	event := Event{
+		ID:         "evt-1",
+		TeamID:     "team-1",
+		Event:      EventInfo{Type: "test.event"},
+		Integrity:  &Integrity{Hash: "stale"},
+		Signature:  &Signature{Value: "stale"},
+		Batch:      &BatchRef{ID: "stale"},
+		Truncation: &Truncation{Fields: []string{"stale"}},
+	}
+	payload, _ := json.Marshal(event)
+	data, _ := json.Marshal(DeadLetter{Payload: payload})
+	reader := &sliceReader{messages: [][]byte{data}}
+
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{})
+
+	result, err := Replay(context.Background(), c, reader, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Replayed)
+
+	require.Len(t, mock.producedMessages, 1)
+	var replayed Event
+	require.NoError(t, json.Unmarshal(mock.producedMessages[0].value, &replayed))
+	assert.Nil(t, replayed.Integrity)
+	assert.Nil(t, replayed.Signature)
+	assert.Nil(t, replayed.Batch)
+	assert.Nil(t, replayed.Truncation)
+}
+
+func TestReplay_FixError(t *testing.T) {
+	payload, _ := json.Marshal(Event{ID: "evt-1"})
+	data, _ := json.Marshal(DeadLetter{Payload: payload})
+	reader := &sliceReader{messages: [][]byte{data, data}}
+
+	boom := errors.New("boom")
+	result, err := Replay(context.Background(), nil, reader, func(*DeadLetter, *Event) error { return boom })
+	assert.ErrorIs(t, err, boom)
+	assert.Equal(t, 1, result.Read)
+	assert.Zero(t, reader.committed)
+}
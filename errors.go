@@ -6,6 +6,20 @@ var (
 	ErrNoBrokers      = errors.New("audit: no kafka brokers configured")
 	ErrEmptyTeamID    = errors.New("audit: teamId is required")
 	ErrEmptyEventType = errors.New("audit: event type is required")
-)
+	ErrEmptyKeyID     = errors.New("audit: signing key id is required")
+	ErrEventTooLarge  = errors.New("audit: event exceeds the maximum size")
+	ErrEventDropped   = errors.New("audit: event dropped")
+	ErrNoSpoolDir     = errors.New("audit: spool policy requires a spool directory")
+	ErrInvalidRoute   = errors.New("audit: invalid route")
+
+	ErrIdempotenceAcks     = errors.New("audit: idempotent production requires RequiredAcks -1 (all)")
+	ErrIdempotenceInFlight = errors.New("audit: idempotent production allows at most 5 in-flight requests")
+	ErrTransactionalQueue  = errors.New("audit: transactional production does not support QueueFullPolicy")
 
+	ErrInvalidDelegation = errors.New("audit: invalid actor delegation")
+	ErrTooManyTargets    = errors.New("audit: event has too many targets")
 
+	ErrNoCheckpointSigner = errors.New("audit: checkpoints require a signer")
+	ErrInvalidBatch       = errors.New("audit: invalid checkpoint batch")
+	ErrInclusionProof     = errors.New("audit: inclusion proof verification failed")
+)
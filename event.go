@@ -19,16 +19,24 @@ const (
 )
 
 type Event struct {
-	Version   string    `json:"version"`
-	ID        string    `json:"id"`
-	Timestamp Timestamp `json:"timestamp"`
-	TeamID    string    `json:"team_id"`
-	Event     EventInfo `json:"event"`
-	Target    Target    `json:"target"`
-	Actor     *Actor    `json:"actor"`
-	Context   *Context  `json:"context"`
-	Changes   *Changes  `json:"changes,omitempty"`
-	Metadata  *Metadata `json:"metadata,omitempty"`
+	Version    string      `json:"version"`
+	ID         string      `json:"id"`
+	Timestamp  Timestamp   `json:"timestamp"`
+	TeamID     string      `json:"team_id"`
+	Event      EventInfo   `json:"event"`
+	Target     Target      `json:"target"`
+	Targets    []Target    `json:"targets,omitempty"`
+	Actor      *Actor      `json:"actor"`
+	Context    *Context    `json:"context"`
+	Source     *Source     `json:"source,omitempty"`
+	Changes    *Changes    `json:"changes,omitempty"`
+	Metadata   *Metadata   `json:"metadata,omitempty"`
+	Integrity  *Integrity  `json:"integrity,omitempty"`
+	Signature  *Signature  `json:"signature,omitempty"`
+	Batch      *BatchRef   `json:"batch,omitempty"`
+	Truncation *Truncation `json:"truncation,omitempty"`
+
+	TargetSummary *TargetSummary `json:"target_summary,omitempty"`
 }
 
 type EventInfo struct {
@@ -40,22 +48,93 @@ type EventInfo struct {
 }
 
 type Actor struct {
-	ID    string    `json:"id"`
-	Email string    `json:"email,omitempty"`
-	Type  ActorType `json:"type"`
+	ID         string    `json:"id"`
+	Email      string    `json:"email,omitempty"`
+	Type       ActorType `json:"type"`
+	TenantID   string    `json:"tenant_id,omitempty"`
+	OnBehalfOf *Actor    `json:"on_behalf_of,omitempty"`
+
+	Delegation []DelegationLink `json:"delegation,omitempty"`
+}
+
+type DelegationLink struct {
+	ID     string    `json:"id"`
+	Email  string    `json:"email,omitempty"`
+	Type   ActorType `json:"type"`
+	Reason string    `json:"reason,omitempty"`
 }
 
 type Target struct {
+	Type      string      `json:"type"`
+	ID        string      `json:"id"`
+	Name      string      `json:"name,omitempty"`
+	Role      string      `json:"role,omitempty"`
+	Ancestors *TargetPath `json:"ancestors,omitempty"`
+}
+
+// TargetPath lists the resources that contain a target, ordered from the root
+// of the hierarchy down to the direct parent. Target holds it by pointer so
+// that Target stays comparable.
+type TargetPath []TargetRef
+
+// TargetRef identifies a resource that contains a target.
+type TargetRef struct {
 	Type string `json:"type"`
 	ID   string `json:"id"`
 	Name string `json:"name,omitempty"`
 }
 
+type TargetSummary struct {
+	Total   int            `json:"total"`
+	Omitted int            `json:"omitted"`
+	ByType  map[string]int `json:"by_type,omitempty"`
+}
+
+type Source struct {
+	Service     string `json:"service,omitempty"`
+	Version     string `json:"version,omitempty"`
+	Environment string `json:"environment,omitempty"`
+	Hostname    string `json:"hostname,omitempty"`
+	Pod         string `json:"pod,omitempty"`
+	Namespace   string `json:"namespace,omitempty"`
+	Node        string `json:"node,omitempty"`
+	PID         int    `json:"pid,omitempty"`
+	SDKVersion  string `json:"sdk_version,omitempty"`
+	GoVersion   string `json:"go_version,omitempty"`
+}
+
 type Context struct {
 	IPAddress string `json:"ip_address,omitempty"`
 	UserAgent string `json:"user_agent,omitempty"`
 	SessionID string `json:"session_id,omitempty"`
 	RequestID string `json:"request_id,omitempty"`
+	TraceID   string `json:"trace_id,omitempty"`
+	SpanID    string `json:"span_id,omitempty"`
+	Geo       *Geo   `json:"geo,omitempty"`
+
+	UserAgentInfo *UserAgentInfo `json:"user_agent_info,omitempty"`
+}
+
+type UserAgentInfo struct {
+	Browser        string `json:"browser,omitempty"`
+	BrowserVersion string `json:"browser_version,omitempty"`
+	OS             string `json:"os,omitempty"`
+	OSVersion      string `json:"os_version,omitempty"`
+	DeviceType     string `json:"device_type,omitempty"`
+	Bot            bool   `json:"bot,omitempty"`
+	Client         string `json:"client,omitempty"`
+	ClientVersion  string `json:"client_version,omitempty"`
+}
+
+type Geo struct {
+	CountryCode string  `json:"country_code,omitempty"`
+	Country     string  `json:"country,omitempty"`
+	Region      string  `json:"region,omitempty"`
+	City        string  `json:"city,omitempty"`
+	Latitude    float64 `json:"latitude,omitempty"`
+	Longitude   float64 `json:"longitude,omitempty"`
+	ASN         uint    `json:"asn,omitempty"`
+	ASOrg       string  `json:"as_org,omitempty"`
 }
 
 type Changes struct {
@@ -65,6 +144,32 @@ type Changes struct {
 
 type Metadata map[string]interface{}
 
+type Integrity struct {
+	ChainID    string `json:"chain_id"`
+	ProducerID string `json:"producer_id"`
+	Sequence   uint64 `json:"sequence"`
+	PrevHash   string `json:"prev_hash"`
+	Hash       string `json:"hash"`
+}
+
+type Truncation struct {
+	OriginalSize   int      `json:"original_size"`
+	Fields         []string `json:"fields,omitempty"`
+	ChangesDropped bool     `json:"changes_dropped,omitempty"`
+	ChangesDigest  string   `json:"changes_digest,omitempty"`
+}
+
+type BatchRef struct {
+	ID    string `json:"id"`
+	Index int    `json:"index"`
+}
+
+type Signature struct {
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Value     string `json:"value"`
+}
+
 type Timestamp struct {
 	time.Time
 }
@@ -0,0 +1,265 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+type HealthStatus string
+
+const (
+	HealthOK       HealthStatus = "ok"
+	HealthDegraded HealthStatus = "degraded"
+	HealthDown     HealthStatus = "down"
+)
+
+const (
+	DefaultHealthWindow       = 1 * time.Minute
+	DefaultMaxFailureRate     = 0.05
+	DefaultMaxQueueSaturation = 0.9
+	DefaultHealthTimeout      = 5 * time.Second
+)
+
+type HealthCheck struct {
+	Name    string       `json:"name"`
+	Status  HealthStatus `json:"status"`
+	Message string       `json:"message,omitempty"`
+}
+
+type HealthReport struct {
+	Status              HealthStatus  `json:"status"`
+	CheckedAt           time.Time     `json:"checked_at"`
+	Checks              []HealthCheck `json:"checks"`
+	Brokers             int           `json:"brokers"`
+	MissingTopics       []string      `json:"missing_topics,omitempty"`
+	Deliveries          int           `json:"deliveries"`
+	DeliveryFailures    int           `json:"delivery_failures"`
+	DeliveryFailureRate float64       `json:"delivery_failure_rate"`
+	QueueDepth          int           `json:"queue_depth"`
+	QueueCapacity       int           `json:"queue_capacity,omitempty"`
+	QueueSaturation     float64       `json:"queue_saturation"`
+	Dropped             int64         `json:"dropped"`
+	SpoolDepth          int           `json:"spool_depth"`
+	SpoolBytes          int64         `json:"spool_bytes"`
+
+	closed bool
+}
+
+// Live reports whether the client can still make progress on its own. Only
+// a closed client is not live: broker outages are retried by the producer,
+// so restarting the process would not help.
+func (r HealthReport) Live() bool {
+	return !r.closed
+}
+
+// Ready reports whether events emitted now are expected to be delivered.
+func (r HealthReport) Ready() bool {
+	return r.Status != HealthDown
+}
+
+func (r *HealthReport) add(name string, status HealthStatus, message string) {
+	r.Checks = append(r.Checks, HealthCheck{Name: name, Status: status, Message: message})
+	if severity(status) > severity(r.Status) {
+		r.Status = status
+	}
+}
+
+func severity(status HealthStatus) int {
+	switch status {
+	case HealthDown:
+		return 2
+	case HealthDegraded:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// HealthReporter is implemented by clients that can report their health.
+type HealthReporter interface {
+	Health(ctx context.Context) HealthReport
+}
+
+var _ HealthReporter = (*client)(nil)
+
+type healthChecker interface {
+	CheckTopics(ctx context.Context, topics []string) (int, []string, error)
+	QueueCapacity() int
+}
+
+func (c *client) Health(ctx context.Context) HealthReport {
+	cfg := c.healthConfig()
+	report := HealthReport{Status: HealthOK, CheckedAt: time.Now().UTC()}
+
+	c.mu.RLock()
+	report.closed = c.closed
+	c.mu.RUnlock()
+	if report.closed {
+		report.add("client", HealthDown, "client is closed")
+		return report
+	}
+
+	checker, ok := c.producer.(healthChecker)
+	if ok {
+		ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+		brokers, missing, err := checker.CheckTopics(ctx, c.healthTopics())
+		cancel()
+
+		switch {
+		case err != nil:
+			report.add("brokers", HealthDown, err.Error())
+		case brokers == 0:
+			report.add("brokers", HealthDown, "no brokers available")
+		default:
+			report.Brokers = brokers
+			report.add("brokers", HealthOK, "")
+			if len(missing) > 0 {
+				report.MissingTopics = missing
+				report.add("topics", HealthDown, "missing topics: "+strings.Join(missing, ", "))
+			} else {
+				report.add("topics", HealthOK, "")
+			}
+		}
+	}
+
+	report.Deliveries, report.DeliveryFailures = c.deliveries.counts(time.Now())
+	if report.Deliveries > 0 {
+		report.DeliveryFailureRate = float64(report.DeliveryFailures) / float64(report.Deliveries)
+	}
+	if report.DeliveryFailureRate > cfg.MaxFailureRate {
+		report.add("delivery", HealthDegraded, fmt.Sprintf("%d of %d deliveries failed in the last %s",
+			report.DeliveryFailures, report.Deliveries, cfg.Window))
+	} else {
+		report.add("delivery", HealthOK, "")
+	}
+
+	if q, ok := c.producer.(queueReporter); ok {
+		report.QueueDepth = q.Len()
+	}
+	if ok && checker.QueueCapacity() > 0 {
+		report.QueueCapacity = checker.QueueCapacity()
+		report.QueueSaturation = float64(report.QueueDepth) / float64(report.QueueCapacity)
+	}
+	if report.QueueSaturation > cfg.MaxQueueSaturation {
+		report.add("queue", HealthDegraded, fmt.Sprintf("producer queue is %.0f%% full", report.QueueSaturation*100))
+	} else {
+		report.add("queue", HealthOK, "")
+	}
+
+	if d, ok := c.producer.(dropReporter); ok {
+		report.Dropped = d.Dropped()
+	}
+	if s, ok := c.producer.(spoolReporter); ok {
+		report.SpoolDepth, report.SpoolBytes = s.Spool()
+		if report.SpoolDepth > 0 {
+			report.add("spool", HealthDegraded, fmt.Sprintf("%d events waiting in the spool", report.SpoolDepth))
+		} else {
+			report.add("spool", HealthOK, "")
+		}
+	}
+
+	return report
+}
+
+func (c *client) healthConfig() HealthConfig {
+	var cfg HealthConfig
+	if c.config.Health != nil {
+		cfg = *c.config.Health
+	}
+	cfg.setDefaults()
+	return cfg
+}
+
+func (c *client) healthTopics() []string {
+	topics := append(append([]string(nil), c.topics...), c.router.static()...)
+	return append(topics, c.config.auxiliaryTopics()...)
+}
+
+// HealthHandler serves the health report of c as JSON. With live set, it
+// answers 503 only when the client is not live; otherwise it answers 503
+// when the client is not ready.
+func HealthHandler(c HealthReporter, live bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := c.Health(r.Context())
+
+		healthy := report.Ready()
+		if live {
+			healthy = report.Live()
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if healthy {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
+
+// deliveryWindow counts delivery outcomes in one-second buckets over a
+// sliding window.
+type deliveryWindow struct {
+	mu      sync.Mutex
+	buckets []deliveryBucket
+}
+
+type deliveryBucket struct {
+	second   int64
+	total    int
+	failures int
+}
+
+func newDeliveryWindow(window time.Duration) *deliveryWindow {
+	size := int(window / time.Second)
+	if size < 1 {
+		size = 1
+	}
+	return &deliveryWindow{buckets: make([]deliveryBucket, size)}
+}
+
+func (w *deliveryWindow) record(now time.Time, failed bool) {
+	if w == nil {
+		return
+	}
+
+	second := now.Unix()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	b := &w.buckets[int(second%int64(len(w.buckets)))]
+	if b.second != second {
+		*b = deliveryBucket{second: second}
+	}
+	b.total++
+	if failed {
+		b.failures++
+	}
+}
+
+func (w *deliveryWindow) counts(now time.Time) (int, int) {
+	if w == nil {
+		return 0, 0
+	}
+
+	oldest := now.Unix() - int64(len(w.buckets)) + 1
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var total, failures int
+	for _, b := range w.buckets {
+		if b.second >= oldest {
+			total += b.total
+			failures += b.failures
+		}
+	}
+	return total, failures
+}
@@ -0,0 +1,163 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/NeuralTrust/audit-sdk-go/kafka"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type healthProducer struct {
+	queueingProducer
+	brokers  int
+	existing map[string]bool
+	err      error
+	capacity int
+}
+
+func (p *healthProducer) CheckTopics(ctx context.Context, topics []string) (int, []string, error) {
+	if p.err != nil {
+		return 0, nil, p.err
+	}
+	var missing []string
+	for _, topic := range topics {
+		if !p.existing[topic] {
+			missing = append(missing, topic)
+		}
+	}
+	return p.brokers, missing, nil
+}
+
+func (p *healthProducer) QueueCapacity() int { return p.capacity }
+
+func findCheck(report HealthReport, name string) HealthCheck {
+	for _, check := range report.Checks {
+		if check.Name == name {
+			return check
+		}
+	}
+	return HealthCheck{}
+}
+
+func TestClient_Health(t *testing.T) {
+	producer := &healthProducer{
+		brokers:  3,
+		existing: map[string]bool{"events": true, "logs": true},
+		capacity: 100,
+	}
+	c := newTestClient(producer, &Config{}, "events", "logs")
+
+	report := c.Health(context.Background())
+	assert.Equal(t, HealthOK, report.Status)
+	assert.Equal(t, 3, report.Brokers)
+	assert.True(t, report.Live())
+	assert.True(t, report.Ready())
+	assert.Equal(t, HealthOK, findCheck(report, "topics").Status)
+}
+
+func TestClient_Health_BrokersDown(t *testing.T) {
+	c := newTestClient(&healthProducer{err: errors.New("Local: Broker transport failure")}, &Config{}, "events", "logs")
+
+	report := c.Health(context.Background())
+	assert.Equal(t, HealthDown, report.Status)
+	assert.Contains(t, findCheck(report, "brokers").Message, "transport failure")
+	assert.True(t, report.Live())
+	assert.False(t, report.Ready())
+}
+
+func TestClient_Health_MissingTopics(t *testing.T) {
+	c := newTestClient(&healthProducer{brokers: 1, existing: map[string]bool{"events": true}}, &Config{}, "events", "logs")
+
+	report := c.Health(context.Background())
+	assert.Equal(t, HealthDown, report.Status)
+	assert.Equal(t, []string{"logs"}, report.MissingTopics)
+}
+
+func TestClient_Health_DeliveryFailures(t *testing.T) {
+	c := newTestClient(&healthProducer{brokers: 1, existing: map[string]bool{"events": true, "logs": true}}, &Config{}, "events", "logs")
+
+	for i := 0; i < 8; i++ {
+		c.handleDelivery(kafka.DeliveryReport{Topic: "events", Enqueued: true})
+	}
+	for i := 0; i < 2; i++ {
+		c.handleDelivery(kafka.DeliveryReport{Topic: "events", Enqueued: true, Err: errors.New("timed out")})
+	}
+
+	report := c.Health(context.Background())
+	assert.Equal(t, HealthDegraded, report.Status)
+	assert.Equal(t, 10, report.Deliveries)
+	assert.Equal(t, 2, report.DeliveryFailures)
+	assert.InDelta(t, 0.2, report.DeliveryFailureRate, 1e-9)
+	assert.True(t, report.Ready())
+}
+
+func TestClient_Health_QueueSaturation(t *testing.T) {
+	producer := &healthProducer{brokers: 1, existing: map[string]bool{"events": true, "logs": true}, capacity: 10}
+	c := newTestClient(producer, &Config{}, "events", "logs")
+	for i := 0; i < 10; i++ {
+		producer.ProduceAsync(c.topics, nil, []byte("{}"))
+	}
+
+	report := c.Health(context.Background())
+	assert.Equal(t, HealthDegraded, report.Status)
+	assert.Equal(t, 10, report.QueueDepth)
+	assert.Equal(t, 1.0, report.QueueSaturation)
+}
+
+func TestClient_Health_SpoolBacklog(t *testing.T) {
+	c := newTestClient(&spoolingProducer{spooled: 3}, &Config{}, "events", "logs")
+
+	report := c.Health(context.Background())
+	assert.Equal(t, HealthDegraded, report.Status)
+	assert.Equal(t, 3, report.SpoolDepth)
+	assert.Equal(t, int64(300), report.SpoolBytes)
+}
+
+func TestClient_Health_Closed(t *testing.T) {
+	c := newTestClient(&mockProducer{}, &Config{}, "events", "logs")
+	require.NoError(t, c.Close())
+
+	report := c.Health(context.Background())
+	assert.Equal(t, HealthDown, report.Status)
+	assert.False(t, report.Live())
+}
+
+func TestDeliveryWindow_Expires(t *testing.T) {
+	w := newDeliveryWindow(5 * time.Second)
+	start := time.Unix(1000, 0)
+
+	w.record(start, true)
+	w.record(start.Add(2*time.Second), false)
+
+	total, failures := w.counts(start.Add(4 * time.Second))
+	assert.Equal(t, 2, total)
+	assert.Equal(t, 1, failures)
+
+	total, failures = w.counts(start.Add(6 * time.Second))
+	assert.Equal(t, 1, total)
+	assert.Equal(t, 0, failures)
+}
+
+func TestHealthHandler(t *testing.T) {
+	c := newTestClient(&healthProducer{err: errors.New("no brokers")}, &Config{}, "events", "logs")
+
+	rec := httptest.NewRecorder()
+	HealthHandler(c, false).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var report HealthReport
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, HealthDown, report.Status)
+
+	rec = httptest.NewRecorder()
+	HealthHandler(c, true).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
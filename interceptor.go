@@ -0,0 +1,77 @@
+package audit
+
+import (
+	"context"
+	"os"
+)
+
+type Next func(ctx context.Context, event *Event) error
+
+// Interceptor wraps the emit pipeline. It runs after the request scope has
+// been applied and before validation, enrichment and encoding. Returning an
+// error rejects the event; returning nil without calling next drops it.
+type Interceptor func(ctx context.Context, event *Event, next Next) error
+
+func chainInterceptors(interceptors []Interceptor, final Next) Next {
+	next := final
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, inner := interceptors[i], next
+		next = func(ctx context.Context, event *Event) error {
+			return interceptor(ctx, event, inner)
+		}
+	}
+	return next
+}
+
+func (c *client) intercept(ctx context.Context, event *Event) error {
+	if len(c.config.Interceptors) == 0 {
+		return c.emit(ctx, event)
+	}
+
+	reached := false
+	final := func(ctx context.Context, event *Event) error {
+		reached = true
+		return c.emit(ctx, event)
+	}
+
+	err := chainInterceptors(c.config.Interceptors, final)(ctx, event)
+	if err != nil && !reached {
+		c.observer().EventRejected(event, err)
+	}
+	return err
+}
+
+// StaticMetadata adds the given metadata to every event. Keys already set on
+// the event are kept.
+func StaticMetadata(metadata Metadata) Interceptor {
+	return func(ctx context.Context, event *Event, next Next) error {
+		addMetadata(event, metadata)
+		return next(ctx, event)
+	}
+}
+
+func Hostname() Interceptor {
+	hostname, _ := os.Hostname()
+	return StaticMetadata(Metadata{"hostname": hostname})
+}
+
+func ServiceName(name string) Interceptor {
+	return StaticMetadata(Metadata{"service_name": name})
+}
+
+func ServiceVersion(version string) Interceptor {
+	return StaticMetadata(Metadata{"service_version": version})
+}
+
+func addMetadata(event *Event, metadata Metadata) {
+	merged := make(Metadata, len(metadata))
+	for key, value := range metadata {
+		merged[key] = value
+	}
+	if event.Metadata != nil {
+		for key, value := range *event.Metadata {
+			merged[key] = value
+		}
+	}
+	event.Metadata = &merged
+}
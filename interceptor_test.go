@@ -0,0 +1,89 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterceptors_RunInOrder(t *testing.T) {
+	var calls []string
+	record := func(name string) Interceptor {
+		return func(ctx context.Context, event *Event, next Next) error {
+			calls = append(calls, name+":before")
+			err := next(ctx, event)
+			calls = append(calls, name+":after")
+			return err
+		}
+	}
+
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{Interceptors: []Interceptor{record("first"), record("second")}})
+
+	require.NoError(t, c.Emit(Event{TeamID: "team-1", Event: EventInfo{Type: "test.event"}}))
+	assert.Equal(t, []string{"first:before", "second:before", "second:after", "first:after"}, calls)
+	assert.Len(t, mock.producedMessages, 1)
+}
+
+func TestInterceptors_RunBeforeValidation(t *testing.T) {
+	tenancy := func(ctx context.Context, event *Event, next Next) error {
+		if event.TeamID == "" {
+			event.TeamID = "team-default"
+		}
+		return next(ctx, event)
+	}
+
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{Interceptors: []Interceptor{tenancy}})
+
+	require.NoError(t, c.Emit(Event{Event: EventInfo{Type: "test.event"}}))
+	assert.Equal(t, []byte("team-default"), mock.producedMessages[0].key)
+}
+
+func TestInterceptors_RejectAndDrop(t *testing.T) {
+	errRejected := errors.New("rejected")
+
+	mock := &mockProducer{}
+	sampler := func(ctx context.Context, event *Event, next Next) error {
+		switch event.Event.Type {
+		case "rejected":
+			return errRejected
+		case "sampled":
+			return nil
+		}
+		return next(ctx, event)
+	}
+	c := newTestClient(mock, &Config{Interceptors: []Interceptor{sampler}})
+
+	assert.Equal(t, errRejected, c.Emit(Event{TeamID: "team-1", Event: EventInfo{Type: "rejected"}}))
+	assert.NoError(t, c.Emit(Event{TeamID: "team-1", Event: EventInfo{Type: "sampled"}}))
+	assert.Empty(t, mock.producedMessages)
+}
+
+func TestBuiltinInterceptors(t *testing.T) {
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{Interceptors: []Interceptor{
+		Hostname(),
+		ServiceName("gateway-api"),
+		ServiceVersion("1.4.2"),
+		StaticMetadata(Metadata{"region": "eu", "service_name": "ignored"}),
+	}})
+
+	metadata := Metadata{"region": "us"}
+	require.NoError(t, c.Emit(Event{TeamID: "team-1", Event: EventInfo{Type: "test.event"}, Metadata: &metadata}))
+	assert.Equal(t, Metadata{"region": "us"}, metadata, "caller's metadata must not be modified")
+
+	var event Event
+	require.NoError(t, json.Unmarshal(mock.producedMessages[0].value, &event))
+
+	hostname, _ := os.Hostname()
+	assert.Equal(t, hostname, (*event.Metadata)["hostname"])
+	assert.Equal(t, "gateway-api", (*event.Metadata)["service_name"])
+	assert.Equal(t, "1.4.2", (*event.Metadata)["service_version"])
+	assert.Equal(t, "us", (*event.Metadata)["region"])
+}
@@ -0,0 +1,335 @@
+package kafka
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+)
+
+// QueueFullPolicy decides what happens to a message when librdkafka's local
+// queue is full.
+type QueueFullPolicy string
+
+const (
+	// QueueFullBlock waits up to BlockTimeout for queue space.
+	QueueFullBlock QueueFullPolicy = "block"
+	// QueueFullDropNewest drops the message being produced.
+	QueueFullDropNewest QueueFullPolicy = "drop_newest"
+	// QueueFullDropOldest buffers messages in memory, up to OverflowBuffer,
+	// and drops the oldest buffered message when that fills up too.
+	QueueFullDropOldest QueueFullPolicy = "drop_oldest"
+	// QueueFullSpool writes messages to SpoolDir and replays them once the
+	// queue drains. The spool survives restarts.
+	QueueFullSpool QueueFullPolicy = "spool"
+)
+
+const (
+	DefaultBlockTimeout   = 5 * time.Second
+	DefaultOverflowBuffer = 10000
+	DefaultSpoolMaxBytes  = 1 << 30
+)
+
+type DropReason string
+
+const (
+	DropQueueFull    DropReason = "queue_full"
+	DropBlockTimeout DropReason = "block_timeout"
+	DropOverflow     DropReason = "overflow"
+	DropSpoolFull    DropReason = "spool_full"
+	DropSpoolError   DropReason = "spool_error"
+	DropClosed       DropReason = "closed"
+)
+
+// DropReport describes a message that was discarded by the queue-full
+// policy. Err is set for spool I/O failures. Opaque is the value passed to
+// ProduceAsyncOpaque.
+type DropReport struct {
+	Topic  string
+	Key    []byte
+	Value  []byte
+	Reason DropReason
+	Err    error
+	Opaque interface{}
+}
+
+var ErrNoSpoolDir = errors.New("kafka: spool policy requires a spool directory")
+
+const drainInterval = 50 * time.Millisecond
+
+// backpressure applies a QueueFullPolicy in front of the librdkafka queue.
+// Buffered and spooled messages are replayed in order by a background
+// goroutine, and new messages queue up behind them.
+type backpressure struct {
+	policy  QueueFullPolicy
+	timeout time.Duration
+	produce func(*kafka.Message) error
+	fail    func(*kafka.Message, error)
+	drop    func(DropReport)
+
+	mu       sync.Mutex
+	ring     []*kafka.Message
+	ringCap  int
+	spool    *spool
+	deferred []func()
+
+	dropped atomic.Int64
+	space   chan struct{}
+	closed  chan struct{}
+	wg      sync.WaitGroup
+}
+
+func newBackpressure(cfg *Config, produce func(*kafka.Message) error, fail func(*kafka.Message, error)) (*backpressure, error) {
+	b := &backpressure{
+		policy:  cfg.QueueFullPolicy,
+		timeout: cfg.BlockTimeout,
+		produce: produce,
+		fail:    fail,
+		drop:    cfg.OnDrop,
+		ringCap: cfg.OverflowBuffer,
+		space:   make(chan struct{}, 1),
+		closed:  make(chan struct{}),
+	}
+
+	if b.policy == QueueFullSpool {
+		if cfg.SpoolDir == "" {
+			return nil, ErrNoSpoolDir
+		}
+		s, err := openSpool(cfg.SpoolDir, cfg.SpoolMaxBytes)
+		if err != nil {
+			return nil, err
+		}
+		b.spool = s
+	}
+
+	if b.buffers() {
+		b.wg.Add(1)
+		go b.drainLoop()
+	}
+
+	return b, nil
+}
+
+func (b *backpressure) buffers() bool {
+	return b.policy == QueueFullDropOldest || b.policy == QueueFullSpool
+}
+
+// send produces msg, applying the policy if the queue is full. Errors other
+// than a full queue are returned to the caller.
+func (b *backpressure) send(msg *kafka.Message) error {
+	if b.buffers() {
+		b.mu.Lock()
+		defer b.unlock()
+
+		if b.pendingLocked() == 0 {
+			if err := b.produce(msg); !isQueueFull(err) {
+				return err
+			}
+		}
+		b.bufferLocked(msg)
+		return nil
+	}
+
+	err := b.produce(msg)
+	if !isQueueFull(err) {
+		return err
+	}
+
+	if b.policy == QueueFullBlock {
+		return b.block(msg)
+	}
+	b.discard(msg, DropQueueFull, nil)
+	return nil
+}
+
+func (b *backpressure) block(msg *kafka.Message) error {
+	timer := time.NewTimer(b.timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-b.space:
+		case <-time.After(drainInterval):
+		case <-timer.C:
+			b.discard(msg, DropBlockTimeout, nil)
+			return nil
+		case <-b.closed:
+			b.discard(msg, DropClosed, nil)
+			return nil
+		}
+
+		if err := b.produce(msg); !isQueueFull(err) {
+			return err
+		}
+	}
+}
+
+func (b *backpressure) bufferLocked(msg *kafka.Message) {
+	if b.spool != nil {
+		err := b.spool.push(msg)
+		switch {
+		case errors.Is(err, errSpoolFull):
+			b.discardLocked(msg, DropSpoolFull, nil)
+		case err != nil:
+			b.discardLocked(msg, DropSpoolError, err)
+		}
+		return
+	}
+
+	if b.ringCap > 0 && len(b.ring) >= b.ringCap {
+		oldest := b.ring[0]
+		b.ring[0] = nil
+		b.ring = b.ring[1:]
+		b.discardLocked(oldest, DropOverflow, nil)
+	}
+	b.ring = append(b.ring, msg)
+}
+
+// signal wakes the drain loop and blocked senders after a delivery report
+// has freed queue space.
+func (b *backpressure) signal() {
+	select {
+	case b.space <- struct{}{}:
+	default:
+	}
+}
+
+func (b *backpressure) drainLoop() {
+	defer b.wg.Done()
+
+	ticker := time.NewTicker(drainInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.closed:
+			return
+		case <-b.space:
+		case <-ticker.C:
+		}
+		b.drain()
+	}
+}
+
+// drain moves buffered messages into the producer queue until it is full
+// again or the buffer is empty.
+func (b *backpressure) drain() {
+	b.mu.Lock()
+	defer b.unlock()
+
+	for b.pendingLocked() > 0 {
+		if b.spool != nil {
+			msg, err := b.spool.peek()
+			if err != nil {
+				b.discardLocked(&kafka.Message{}, DropSpoolError, err)
+				_ = b.spool.pop()
+				continue
+			}
+			if err := b.produce(msg); isQueueFull(err) {
+				return
+			} else if err != nil {
+				b.failLocked(msg, err)
+			}
+			_ = b.spool.pop()
+			continue
+		}
+
+		msg := b.ring[0]
+		if err := b.produce(msg); isQueueFull(err) {
+			return
+		} else if err != nil {
+			b.failLocked(msg, err)
+		}
+		b.ring[0] = nil
+		b.ring = b.ring[1:]
+	}
+}
+
+func (b *backpressure) pending() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.pendingLocked()
+}
+
+func (b *backpressure) pendingLocked() int {
+	if b.spool != nil {
+		return b.spool.len()
+	}
+	return len(b.ring)
+}
+
+func (b *backpressure) spoolSize() (int, int64) {
+	if b.spool == nil {
+		return 0, 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.spool.len(), b.spool.bytes
+}
+
+// unlock releases mu and then runs the callbacks queued while it was held,
+// so that they may call back into the producer.
+func (b *backpressure) unlock() {
+	deferred := b.deferred
+	b.deferred = nil
+	b.mu.Unlock()
+
+	for _, fn := range deferred {
+		fn()
+	}
+}
+
+func (b *backpressure) discard(msg *kafka.Message, reason DropReason, err error) {
+	b.dropped.Add(1)
+	if b.drop != nil {
+		b.drop(dropReport(msg, reason, err))
+	}
+}
+
+func (b *backpressure) discardLocked(msg *kafka.Message, reason DropReason, err error) {
+	b.dropped.Add(1)
+	if b.drop != nil {
+		report := dropReport(msg, reason, err)
+		b.deferred = append(b.deferred, func() { b.drop(report) })
+	}
+}
+
+func (b *backpressure) failLocked(msg *kafka.Message, err error) {
+	b.deferred = append(b.deferred, func() { b.fail(msg, err) })
+}
+
+func dropReport(msg *kafka.Message, reason DropReason, err error) DropReport {
+	report := DropReport{Key: msg.Key, Value: msg.Value, Reason: reason, Err: err, Opaque: opaqueOf(msg)}
+	if msg.TopicPartition.Topic != nil {
+		report.Topic = *msg.TopicPartition.Topic
+	}
+	return report
+}
+
+// close stops the drain loop and gives buffered messages until timeout to
+// reach the producer queue. Messages left in memory are dropped; spooled
+// messages stay on disk for the next process.
+func (b *backpressure) close(flush func(timeoutMs int) int, timeout time.Duration) {
+	close(b.closed)
+	b.wg.Wait()
+
+	deadline := time.Now().Add(timeout)
+	for b.pending() > 0 && time.Now().Before(deadline) {
+		b.drain()
+		flush(int(drainInterval.Milliseconds()))
+	}
+
+	b.mu.Lock()
+	defer b.unlock()
+	for _, msg := range b.ring {
+		b.discardLocked(msg, DropClosed, nil)
+	}
+	b.ring = nil
+}
+
+func isQueueFull(err error) bool {
+	var kerr kafka.Error
+	return errors.As(err, &kerr) && kerr.Code() == kafka.ErrQueueFull
+}
@@ -0,0 +1,221 @@
+package kafka
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeQueue struct {
+	mu       sync.Mutex
+	capacity int
+	values   []string
+	opaques  []interface{}
+}
+
+func (q *fakeQueue) produce(msg *kafka.Message) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.values) >= q.capacity {
+		return kafka.NewError(kafka.ErrQueueFull, "Local: Queue full", false)
+	}
+	q.values = append(q.values, string(msg.Value))
+	q.opaques = append(q.opaques, opaqueOf(msg))
+	return nil
+}
+
+func (q *fakeQueue) produced() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]string(nil), q.values...)
+}
+
+func (q *fakeQueue) grow(n int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.capacity += n
+}
+
+type dropRecorder struct {
+	mu    sync.Mutex
+	drops []DropReport
+}
+
+func (r *dropRecorder) record(report DropReport) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.drops = append(r.drops, report)
+}
+
+func (r *dropRecorder) reasons() map[string]DropReason {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reasons := make(map[string]DropReason)
+	for _, d := range r.drops {
+		reasons[string(d.Value)] = d.Reason
+	}
+	return reasons
+}
+
+func testMessage(value string) *kafka.Message {
+	topic := "events"
+	return &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
+		Value:          []byte(value),
+	}
+}
+
+func newTestBackpressure(t *testing.T, cfg *Config, queue *fakeQueue) (*backpressure, *dropRecorder) {
+	t.Helper()
+
+	drops := &dropRecorder{}
+	cfg.OnDrop = drops.record
+	b, err := newBackpressure(cfg, queue.produce, func(*kafka.Message, error) {})
+	require.NoError(t, err)
+	return b, drops
+}
+
+func noFlush(int) int { return 0 }
+
+func TestBackpressure_DropNewest(t *testing.T) {
+	queue := &fakeQueue{capacity: 1}
+	b, drops := newTestBackpressure(t, &Config{QueueFullPolicy: QueueFullDropNewest}, queue)
+
+	require.NoError(t, b.send(testMessage("a")))
+	require.NoError(t, b.send(testMessage("b")))
+
+	assert.Equal(t, []string{"a"}, queue.produced())
+	assert.Equal(t, map[string]DropReason{"b": DropQueueFull}, drops.reasons())
+	assert.Equal(t, int64(1), b.dropped.Load())
+	assert.Equal(t, "events", drops.drops[0].Topic)
+}
+
+func TestBackpressure_Block(t *testing.T) {
+	queue := &fakeQueue{capacity: 1}
+	b, drops := newTestBackpressure(t, &Config{QueueFullPolicy: QueueFullBlock, BlockTimeout: 5 * time.Second}, queue)
+
+	require.NoError(t, b.send(testMessage("a")))
+
+	done := make(chan error)
+	go func() { done <- b.send(testMessage("b")) }()
+
+	time.Sleep(20 * time.Millisecond)
+	queue.grow(1)
+	b.signal()
+
+	require.NoError(t, <-done)
+	assert.Equal(t, []string{"a", "b"}, queue.produced())
+	assert.Empty(t, drops.reasons())
+}
+
+func TestBackpressure_BlockTimeout(t *testing.T) {
+	queue := &fakeQueue{capacity: 0}
+	b, drops := newTestBackpressure(t, &Config{QueueFullPolicy: QueueFullBlock, BlockTimeout: 10 * time.Millisecond}, queue)
+
+	require.NoError(t, b.send(testMessage("a")))
+	assert.Equal(t, map[string]DropReason{"a": DropBlockTimeout}, drops.reasons())
+}
+
+func TestBackpressure_DropOldest(t *testing.T) {
+	queue := &fakeQueue{capacity: 1}
+	b, drops := newTestBackpressure(t, &Config{QueueFullPolicy: QueueFullDropOldest, OverflowBuffer: 2}, queue)
+
+	for _, value := range []string{"a", "b", "c", "d"} {
+		require.NoError(t, b.send(testMessage(value)))
+	}
+	assert.Equal(t, []string{"a"}, queue.produced())
+	assert.Equal(t, map[string]DropReason{"b": DropOverflow}, drops.reasons())
+
+	queue.grow(1)
+	b.drain()
+	assert.Equal(t, []string{"a", "c"}, queue.produced())
+
+	// New messages queue up behind the buffered ones.
+	queue.grow(2)
+	require.NoError(t, b.send(testMessage("e")))
+	b.drain()
+	assert.Equal(t, []string{"a", "c", "d", "e"}, queue.produced())
+
+	b.close(noFlush, 0)
+}
+
+func TestBackpressure_CloseDropsBuffered(t *testing.T) {
+	queue := &fakeQueue{capacity: 0}
+	b, drops := newTestBackpressure(t, &Config{QueueFullPolicy: QueueFullDropOldest, OverflowBuffer: 10}, queue)
+
+	require.NoError(t, b.send(testMessage("a")))
+	b.close(noFlush, 0)
+
+	assert.Equal(t, map[string]DropReason{"a": DropClosed}, drops.reasons())
+}
+
+func TestBackpressure_Spool(t *testing.T) {
+	dir := t.TempDir()
+	queue := &fakeQueue{capacity: 1}
+	b, drops := newTestBackpressure(t, &Config{QueueFullPolicy: QueueFullSpool, SpoolDir: dir}, queue)
+
+	for _, value := range []string{"a", "b", "c"} {
+		require.NoError(t, b.send(testMessage(value)))
+	}
+	assert.Equal(t, []string{"a"}, queue.produced())
+	assert.Empty(t, drops.reasons())
+
+	depth, size := b.spoolSize()
+	assert.Equal(t, 2, depth)
+	assert.Positive(t, size)
+
+	// Spooled messages survive a restart and replay in order.
+	b.close(noFlush, 0)
+
+	queue = &fakeQueue{capacity: 10}
+	b, _ = newTestBackpressure(t, &Config{QueueFullPolicy: QueueFullSpool, SpoolDir: dir}, queue)
+	b.drain()
+	assert.Equal(t, []string{"b", "c"}, queue.produced())
+
+	depth, size = b.spoolSize()
+	assert.Zero(t, depth)
+	assert.Zero(t, size)
+	b.close(noFlush, 0)
+}
+
+func TestBackpressure_SpoolKeepsOpaque(t *testing.T) {
+	queue := &fakeQueue{capacity: 1}
+	b, drops := newTestBackpressure(t, &Config{QueueFullPolicy: QueueFullSpool, SpoolDir: t.TempDir(), SpoolMaxBytes: 128}, queue)
+
+	for _, value := range []string{"a", "b", string(make([]byte, 128))} {
+		msg := testMessage(value)
+		msg.Opaque = &asyncMessage{opaque: value}
+		require.NoError(t, b.send(msg))
+	}
+
+	queue.grow(1)
+	b.drain()
+	assert.Equal(t, []interface{}{"a", "b"}, queue.opaques)
+	require.Len(t, drops.drops, 1)
+	assert.Equal(t, string(make([]byte, 128)), drops.drops[0].Opaque)
+	b.close(noFlush, 0)
+}
+
+func TestBackpressure_SpoolFull(t *testing.T) {
+	queue := &fakeQueue{capacity: 0}
+	b, drops := newTestBackpressure(t, &Config{QueueFullPolicy: QueueFullSpool, SpoolDir: t.TempDir(), SpoolMaxBytes: 64}, queue)
+
+	require.NoError(t, b.send(testMessage("a")))
+	require.NoError(t, b.send(testMessage(string(make([]byte, 64)))))
+	b.close(noFlush, 0)
+
+	depth, _ := b.spoolSize()
+	assert.Equal(t, 1, depth)
+	assert.Len(t, drops.drops, 1)
+	assert.Equal(t, DropSpoolFull, drops.drops[0].Reason)
+}
+
+func TestNewBackpressure_SpoolRequiresDir(t *testing.T) {
+	_, err := newBackpressure(&Config{QueueFullPolicy: QueueFullSpool}, nil, nil)
+	assert.ErrorIs(t, err, ErrNoSpoolDir)
+}
@@ -3,11 +3,14 @@ package kafka
 import (
 	"context"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/confluentinc/confluent-kafka-go/kafka"
 )
 
+const DefaultQueueMaxMessages = 100000
+
 type Config struct {
 	Brokers          []string
 	ClientID         string
@@ -18,6 +21,37 @@ type Config struct {
 	TopicReplication int
 	TLS              *TLSConfig
 	SASL             *SASLConfig
+	OnDelivery       func(DeliveryReport)
+	StatsInterval    time.Duration
+	OnStats          func(Stats)
+	QueueMaxMessages int
+	QueueFullPolicy  QueueFullPolicy
+	BlockTimeout     time.Duration
+	OverflowBuffer   int
+	SpoolDir         string
+	SpoolMaxBytes    int64
+	OnDrop           func(DropReport)
+
+	Idempotent         bool
+	MaxInFlight        int
+	TransactionalID    string
+	TransactionTimeout time.Duration
+	BatchSize          int
+	BatchTimeout       time.Duration
+}
+
+// DeliveryReport describes the outcome of a single produced message. Err is
+// set when the message could not be enqueued or was not acknowledged by the
+// broker. Enqueued is false when Produce itself failed, in which case
+// Latency is zero. Opaque is the value passed to ProduceAsyncOpaque.
+type DeliveryReport struct {
+	Topic    string
+	Key      []byte
+	Value    []byte
+	Err      error
+	Enqueued bool
+	Latency  time.Duration
+	Opaque   interface{}
 }
 
 type TLSConfig struct {
@@ -39,6 +73,11 @@ type Producer struct {
 	kafkaProducer *kafka.Producer
 	adminClient   *kafka.AdminClient
 	config        *Config
+	done          chan struct{}
+	inFlight      atomic.Int64
+	stats         atomic.Pointer[Stats]
+	backpressure  *backpressure
+	transactor    *transactor
 }
 
 func NewProducer(cfg *Config) (*Producer, error) {
@@ -48,6 +87,24 @@ func NewProducer(cfg *Config) (*Producer, error) {
 	if cfg.TopicReplication == 0 {
 		cfg.TopicReplication = 1
 	}
+	if cfg.QueueMaxMessages == 0 {
+		cfg.QueueMaxMessages = DefaultQueueMaxMessages
+	}
+	if cfg.QueueFullPolicy == "" {
+		cfg.QueueFullPolicy = QueueFullDropNewest
+	}
+	if cfg.BlockTimeout == 0 {
+		cfg.BlockTimeout = DefaultBlockTimeout
+	}
+	if cfg.OverflowBuffer == 0 {
+		cfg.OverflowBuffer = DefaultOverflowBuffer
+	}
+	if cfg.SpoolMaxBytes == 0 {
+		cfg.SpoolMaxBytes = DefaultSpoolMaxBytes
+	}
+	if cfg.TransactionalID != "" && cfg.TransactionTimeout == 0 {
+		cfg.TransactionTimeout = DefaultTransactionTimeout
+	}
 
 	kafkaConfig := buildKafkaConfig(cfg)
 
@@ -62,11 +119,150 @@ func NewProducer(cfg *Config) (*Producer, error) {
 		return nil, err
 	}
 
-	return &Producer{
+	producer := &Producer{
 		kafkaProducer: p,
 		adminClient:   admin,
 		config:        cfg,
-	}, nil
+		done:          make(chan struct{}),
+	}
+
+	producer.backpressure, err = newBackpressure(cfg, producer.produce, func(msg *kafka.Message, err error) {
+		producer.report(msg, err, false, 0)
+	})
+	if err != nil {
+		admin.Close()
+		p.Close()
+		return nil, err
+	}
+
+	go producer.handleEvents()
+
+	if cfg.TransactionalID != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.TransactionTimeout)
+		defer cancel()
+		if err := p.InitTransactions(ctx); err != nil {
+			_ = producer.Close()
+			return nil, err
+		}
+		producer.transactor = newTransactor(inFlightProducer{p, &producer.inFlight}, cfg, producer.report)
+	}
+
+	return producer, nil
+}
+
+func (p *Producer) handleEvents() {
+	defer close(p.done)
+
+	for e := range p.kafkaProducer.Events() {
+		switch ev := e.(type) {
+		case *kafka.Message:
+			p.inFlight.Add(-int64(len(ev.Value)))
+
+			var latency time.Duration
+			switch opaque := ev.Opaque.(type) {
+			case *txnMessage:
+				opaque.txn.delivered(ev, time.Since(opaque.enqueued))
+				continue
+			case *syncMessage:
+				opaque.done <- ev
+				p.backpressure.signal()
+				continue
+			case *asyncMessage:
+				latency = time.Since(opaque.enqueued)
+			}
+			p.report(ev, ev.TopicPartition.Error, true, latency)
+			p.backpressure.signal()
+		case *kafka.Stats:
+			p.handleStats(ev.String())
+		}
+	}
+}
+
+func (p *Producer) handleStats(data string) {
+	stats, err := ParseStats([]byte(data))
+	if err != nil {
+		return
+	}
+	p.stats.Store(&stats)
+
+	if p.config.OnStats != nil {
+		p.config.OnStats(stats)
+	}
+}
+
+// Stats returns the most recent librdkafka statistics. It reports false
+// until the first statistics event arrives, or when StatsInterval is unset.
+func (p *Producer) Stats() (Stats, bool) {
+	stats := p.stats.Load()
+	if stats == nil {
+		return Stats{}, false
+	}
+	return *stats, true
+}
+
+func (p *Producer) report(msg *kafka.Message, err error, enqueued bool, latency time.Duration) {
+	if p.config.OnDelivery == nil {
+		return
+	}
+
+	var topic string
+	if msg.TopicPartition.Topic != nil {
+		topic = *msg.TopicPartition.Topic
+	}
+
+	p.config.OnDelivery(DeliveryReport{
+		Topic:    topic,
+		Key:      msg.Key,
+		Value:    msg.Value,
+		Err:      err,
+		Enqueued: enqueued,
+		Latency:  latency,
+		Opaque:   opaqueOf(msg),
+	})
+}
+
+// Len returns the number of messages waiting to be delivered.
+func (p *Producer) Len() int {
+	return p.kafkaProducer.Len()
+}
+
+// QueueCapacity returns the maximum number of messages the producer queue
+// holds before Produce fails with a queue-full error.
+func (p *Producer) QueueCapacity() int {
+	return p.config.QueueMaxMessages
+}
+
+// CheckTopics fetches cluster metadata and returns the number of known
+// brokers and which of topics do not exist.
+func (p *Producer) CheckTopics(ctx context.Context, topics []string) (int, []string, error) {
+	timeout := 5 * time.Second
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	if timeout <= 0 {
+		return 0, nil, context.DeadlineExceeded
+	}
+
+	metadata, err := p.adminClient.GetMetadata(nil, true, int(timeout.Milliseconds()))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var missing []string
+	for _, topic := range topics {
+		tm, ok := metadata.Topics[topic]
+		if !ok || tm.Error.Code() != kafka.ErrNoError {
+			missing = append(missing, topic)
+		}
+	}
+
+	return len(metadata.Brokers), missing, nil
+}
+
+// InFlightBytes returns the total payload size of messages that have been
+// enqueued but not yet acknowledged or failed.
+func (p *Producer) InFlightBytes() int64 {
+	return p.inFlight.Load()
 }
 
 func buildKafkaConfig(cfg *Config) *kafka.ConfigMap {
@@ -82,30 +278,55 @@ func buildKafkaConfig(cfg *Config) *kafka.ConfigMap {
 		"metadata.max.age.ms":      300000,
 	}
 
-	if cfg.SASL != nil && cfg.SASL.Enable {
+	if cfg.QueueMaxMessages > 0 {
+		(*kafkaConfig)["queue.buffering.max.messages"] = cfg.QueueMaxMessages
+	}
+
+	if cfg.Idempotent || cfg.TransactionalID != "" {
+		(*kafkaConfig)["enable.idempotence"] = true
+	}
+	if cfg.MaxInFlight > 0 {
+		(*kafkaConfig)["max.in.flight.requests.per.connection"] = cfg.MaxInFlight
+	}
+	if cfg.TransactionalID != "" {
+		(*kafkaConfig)["transactional.id"] = cfg.TransactionalID
+		if cfg.TransactionTimeout > 0 {
+			(*kafkaConfig)["transaction.timeout.ms"] = int(cfg.TransactionTimeout.Milliseconds())
+		}
+	}
+
+	if cfg.StatsInterval > 0 {
+		(*kafkaConfig)["statistics.interval.ms"] = int(cfg.StatsInterval.Milliseconds())
+	}
+
+	applySecurity(kafkaConfig, cfg.TLS, cfg.SASL)
+
+	return kafkaConfig
+}
+
+func applySecurity(kafkaConfig *kafka.ConfigMap, tls *TLSConfig, sasl *SASLConfig) {
+	if sasl != nil && sasl.Enable {
 		(*kafkaConfig)["security.protocol"] = "SASL_SSL"
-		(*kafkaConfig)["sasl.mechanisms"] = cfg.SASL.Mechanism
-		(*kafkaConfig)["sasl.username"] = cfg.SASL.Username
-		(*kafkaConfig)["sasl.password"] = cfg.SASL.Password
+		(*kafkaConfig)["sasl.mechanisms"] = sasl.Mechanism
+		(*kafkaConfig)["sasl.username"] = sasl.Username
+		(*kafkaConfig)["sasl.password"] = sasl.Password
 	}
 
-	if cfg.TLS != nil && cfg.TLS.Enable {
-		if cfg.SASL == nil || !cfg.SASL.Enable {
+	if tls != nil && tls.Enable {
+		if sasl == nil || !sasl.Enable {
 			(*kafkaConfig)["security.protocol"] = "SSL"
 		}
-		if cfg.TLS.CAFile != "" {
-			(*kafkaConfig)["ssl.ca.location"] = cfg.TLS.CAFile
+		if tls.CAFile != "" {
+			(*kafkaConfig)["ssl.ca.location"] = tls.CAFile
 		}
-		if cfg.TLS.CertFile != "" {
-			(*kafkaConfig)["ssl.certificate.location"] = cfg.TLS.CertFile
+		if tls.CertFile != "" {
+			(*kafkaConfig)["ssl.certificate.location"] = tls.CertFile
 		}
-		if cfg.TLS.KeyFile != "" {
-			(*kafkaConfig)["ssl.key.location"] = cfg.TLS.KeyFile
+		if tls.KeyFile != "" {
+			(*kafkaConfig)["ssl.key.location"] = tls.KeyFile
 		}
-		(*kafkaConfig)["enable.ssl.certificate.verification"] = !cfg.TLS.InsecureSkipVerify
+		(*kafkaConfig)["enable.ssl.certificate.verification"] = !tls.InsecureSkipVerify
 	}
-
-	return kafkaConfig
 }
 
 func (p *Producer) EnsureTopics(topics []string) error {
@@ -153,21 +374,96 @@ func (p *Producer) EnsureTopics(topics []string) error {
 }
 
 func (p *Producer) ProduceAsync(topics []string, key, value []byte) {
-	for _, topic := range topics {
-		t := topic
-		msg := &kafka.Message{
-			TopicPartition: kafka.TopicPartition{Topic: &t, Partition: kafka.PartitionAny},
-			Key:            key,
-			Value:          value,
+	p.ProduceAsyncOpaque(topics, key, value, nil)
+}
+
+// ProduceAsyncOpaque is like ProduceAsync, but the delivery and drop reports
+// of each message carry opaque.
+func (p *Producer) ProduceAsyncOpaque(topics []string, key, value []byte, opaque interface{}) {
+	msgs := newMessages(topics, key, value)
+	for _, msg := range msgs {
+		msg.Opaque = &asyncMessage{opaque: opaque}
+	}
+
+	if p.transactor != nil {
+		p.transactor.produce(msgs)
+		return
+	}
+
+	for _, msg := range msgs {
+		if err := p.backpressure.send(msg); err != nil {
+			p.report(msg, err, false, 0)
 		}
-		_ = p.kafkaProducer.Produce(msg, nil)
 	}
 }
 
+func (p *Producer) produce(msg *kafka.Message) error {
+	msg.Opaque = &asyncMessage{enqueued: time.Now(), opaque: opaqueOf(msg)}
+	return p.enqueue(msg)
+}
+
+// asyncMessage is the Opaque of messages produced by ProduceAsync.
+type asyncMessage struct {
+	enqueued time.Time
+	opaque   interface{}
+}
+
+// opaqueOf returns the value passed to ProduceAsyncOpaque for msg.
+func opaqueOf(msg *kafka.Message) interface{} {
+	switch opaque := msg.Opaque.(type) {
+	case *asyncMessage:
+		return opaque.opaque
+	case *txnMessage:
+		return opaque.opaque
+	}
+	return nil
+}
+
+func (p *Producer) enqueue(msg *kafka.Message) error {
+	p.inFlight.Add(int64(len(msg.Value)))
+	err := p.kafkaProducer.Produce(msg, nil)
+	if err != nil {
+		p.inFlight.Add(-int64(len(msg.Value)))
+	}
+	return err
+}
+
+// Dropped returns the number of messages discarded by the queue-full policy.
+func (p *Producer) Dropped() int64 {
+	return p.backpressure.dropped.Load()
+}
+
+// Spool returns the number and total size of messages waiting in the disk
+// spool.
+func (p *Producer) Spool() (int, int64) {
+	return p.backpressure.spoolSize()
+}
+
+// inFlightProducer keeps the in-flight byte count for messages produced by
+// the transactor.
+type inFlightProducer struct {
+	*kafka.Producer
+	inFlight *atomic.Int64
+}
+
+func (p inFlightProducer) Produce(msg *kafka.Message, deliveryChan chan kafka.Event) error {
+	p.inFlight.Add(int64(len(msg.Value)))
+	err := p.Producer.Produce(msg, deliveryChan)
+	if err != nil {
+		p.inFlight.Add(-int64(len(msg.Value)))
+	}
+	return err
+}
+
 func (p *Producer) Close() error {
+	if p.transactor != nil {
+		p.transactor.close()
+	}
+	p.backpressure.close(p.kafkaProducer.Flush, 5*time.Second)
 	p.kafkaProducer.Flush(5000)
 	p.adminClient.Close()
 	p.kafkaProducer.Close()
+	<-p.done
 	return nil
 }
 
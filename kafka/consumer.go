@@ -0,0 +1,107 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+)
+
+const DefaultIdleTimeout = 10 * time.Second
+
+type ConsumerConfig struct {
+	Brokers  []string
+	ClientID string
+	GroupID  string
+	Topic    string
+	TLS      *TLSConfig
+	SASL     *SASLConfig
+	// IdleTimeout is how long ReadMessage waits for a new message before
+	// treating the topic as drained.
+	IdleTimeout time.Duration
+}
+
+// Consumer reads a single topic from the committed offset of its group, or
+// from the beginning for a new group. Offsets are only committed by Commit.
+type Consumer struct {
+	consumer *kafka.Consumer
+	idle     time.Duration
+	last     *kafka.Message
+}
+
+func NewConsumer(cfg *ConsumerConfig) (*Consumer, error) {
+	if cfg.IdleTimeout == 0 {
+		cfg.IdleTimeout = DefaultIdleTimeout
+	}
+
+	c, err := kafka.NewConsumer(buildConsumerConfig(cfg))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.Subscribe(cfg.Topic, nil); err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	return &Consumer{consumer: c, idle: cfg.IdleTimeout}, nil
+}
+
+func buildConsumerConfig(cfg *ConsumerConfig) *kafka.ConfigMap {
+	kafkaConfig := &kafka.ConfigMap{
+		"bootstrap.servers":        strings.Join(cfg.Brokers, ","),
+		"client.id":                cfg.ClientID,
+		"group.id":                 cfg.GroupID,
+		"auto.offset.reset":        "earliest",
+		"enable.auto.commit":       false,
+		"enable.auto.offset.store": false,
+	}
+
+	applySecurity(kafkaConfig, cfg.TLS, cfg.SASL)
+
+	return kafkaConfig
+}
+
+// ReadMessage returns the value of the next message. It returns io.EOF when
+// no message arrives within the idle timeout.
+func (c *Consumer) ReadMessage(ctx context.Context) ([]byte, error) {
+	deadline := time.Now().Add(c.idle)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if !time.Now().Before(deadline) {
+			return nil, io.EOF
+		}
+
+		msg, err := c.consumer.ReadMessage(100 * time.Millisecond)
+		if err == nil {
+			c.last = msg
+			return msg.Value, nil
+		}
+
+		var kerr kafka.Error
+		if errors.As(err, &kerr) && !kerr.IsFatal() {
+			continue
+		}
+		return nil, err
+	}
+}
+
+// Commit commits the offset of the last message returned by ReadMessage, and
+// so of every message before it in the same partition.
+func (c *Consumer) Commit() error {
+	if c.last == nil {
+		return nil
+	}
+	_, err := c.consumer.CommitMessage(c.last)
+	return err
+}
+
+func (c *Consumer) Close() error {
+	return c.consumer.Close()
+}
@@ -0,0 +1,34 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildConsumerConfig(t *testing.T) {
+	cfg := &ConsumerConfig{
+		Brokers: []string{"broker1:9092", "broker2:9092"},
+		GroupID: "replay",
+		SASL:    &SASLConfig{Enable: true, Mechanism: "PLAIN", Username: "user", Password: "pass"},
+	}
+
+	kafkaConfig := buildConsumerConfig(cfg)
+
+	servers, _ := kafkaConfig.Get("bootstrap.servers", "")
+	assert.Equal(t, "broker1:9092,broker2:9092", servers)
+
+	groupID, _ := kafkaConfig.Get("group.id", "")
+	assert.Equal(t, "replay", groupID)
+
+	reset, _ := kafkaConfig.Get("auto.offset.reset", "")
+	assert.Equal(t, "earliest", reset)
+
+	autoCommit, _ := kafkaConfig.Get("enable.auto.commit", true)
+	assert.Equal(t, false, autoCommit)
+	autoStore, _ := kafkaConfig.Get("enable.auto.offset.store", true)
+	assert.Equal(t, false, autoStore)
+
+	protocol, _ := kafkaConfig.Get("security.protocol", "")
+	assert.Equal(t, "SASL_SSL", protocol)
+}
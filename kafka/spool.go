@@ -0,0 +1,141 @@
+package kafka
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+)
+
+const spoolExt = ".msg"
+
+var errSpoolFull = errors.New("kafka: spool is full")
+
+// spool is an on-disk FIFO of messages that did not fit in the producer
+// queue. Each message is stored in its own file, named after a sequence
+// number, so a spool left behind by a previous process is replayed in order.
+// Opaque values are kept in memory only, so messages spooled by a previous
+// process are replayed without them. It is not safe for concurrent use.
+type spool struct {
+	dir      string
+	maxBytes int64
+	seq      uint64
+	files    []spoolFile
+	bytes    int64
+}
+
+type spoolFile struct {
+	name   string
+	size   int64
+	opaque interface{}
+}
+
+type spoolRecord struct {
+	Topic string `json:"topic"`
+	Key   []byte `json:"key,omitempty"`
+	Value []byte `json:"value"`
+}
+
+func openSpool(dir string, maxBytes int64) (*spool, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &spool{dir: dir, maxBytes: maxBytes}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			continue
+		}
+		if strings.HasSuffix(name, ".tmp") {
+			_ = os.Remove(filepath.Join(dir, name))
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, spoolExt), 10, 64)
+		if err != nil || !strings.HasSuffix(name, spoolExt) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		s.files = append(s.files, spoolFile{name: name, size: info.Size()})
+		s.bytes += info.Size()
+		if seq >= s.seq {
+			s.seq = seq + 1
+		}
+	}
+	sort.Slice(s.files, func(i, j int) bool { return s.files[i].name < s.files[j].name })
+
+	return s, nil
+}
+
+func (s *spool) push(msg *kafka.Message) error {
+	record := spoolRecord{Key: msg.Key, Value: msg.Value}
+	if msg.TopicPartition.Topic != nil {
+		record.Topic = *msg.TopicPartition.Topic
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if s.maxBytes > 0 && s.bytes+int64(len(data)) > s.maxBytes {
+		return errSpoolFull
+	}
+
+	name := fmt.Sprintf("%020d%s", s.seq, spoolExt)
+	path := filepath.Join(s.dir, name)
+	if err := os.WriteFile(path+".tmp", data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return err
+	}
+
+	s.seq++
+	s.files = append(s.files, spoolFile{name: name, size: int64(len(data)), opaque: opaqueOf(msg)})
+	s.bytes += int64(len(data))
+	return nil
+}
+
+// peek returns the oldest spooled message without removing it.
+func (s *spool) peek() (*kafka.Message, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, s.files[0].name))
+	if err != nil {
+		return nil, err
+	}
+
+	var record spoolRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+
+	return &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &record.Topic, Partition: kafka.PartitionAny},
+		Key:            record.Key,
+		Value:          record.Value,
+		Opaque:         &asyncMessage{opaque: s.files[0].opaque},
+	}, nil
+}
+
+func (s *spool) pop() error {
+	file := s.files[0]
+	s.files = s.files[1:]
+	s.bytes -= file.size
+	return os.Remove(filepath.Join(s.dir, file.name))
+}
+
+func (s *spool) len() int {
+	return len(s.files)
+}
@@ -0,0 +1,117 @@
+package kafka
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Stats is the subset of librdkafka's statistics that matters to a
+// producer. See STATISTICS.md in librdkafka for the meaning of each field;
+// latencies are in microseconds.
+type Stats struct {
+	Name       string                 `json:"name"`
+	ClientID   string                 `json:"client_id"`
+	Type       string                 `json:"type"`
+	Timestamp  int64                  `json:"ts"`
+	Time       int64                  `json:"time"`
+	Age        int64                  `json:"age"`
+	MsgCount   int                    `json:"msg_cnt"`
+	MsgSize    int64                  `json:"msg_size"`
+	MsgMax     int                    `json:"msg_max"`
+	MsgSizeMax int64                  `json:"msg_size_max"`
+	Tx         int64                  `json:"tx"`
+	TxBytes    int64                  `json:"tx_bytes"`
+	Rx         int64                  `json:"rx"`
+	RxBytes    int64                  `json:"rx_bytes"`
+	TxMsgs     int64                  `json:"txmsgs"`
+	TxMsgBytes int64                  `json:"txmsg_bytes"`
+	Brokers    map[string]BrokerStats `json:"brokers"`
+	Topics     map[string]TopicStats  `json:"topics"`
+}
+
+type BrokerStats struct {
+	Name           string      `json:"name"`
+	NodeID         int32       `json:"nodeid"`
+	NodeName       string      `json:"nodename"`
+	Source         string      `json:"source"`
+	State          string      `json:"state"`
+	StateAge       int64       `json:"stateage"`
+	OutbufCount    int         `json:"outbuf_cnt"`
+	OutbufMsgCount int         `json:"outbuf_msg_cnt"`
+	WaitRespCount  int         `json:"waitresp_cnt"`
+	Tx             int64       `json:"tx"`
+	TxBytes        int64       `json:"txbytes"`
+	TxErrs         int64       `json:"txerrs"`
+	TxRetries      int64       `json:"txretries"`
+	ReqTimeouts    int64       `json:"req_timeouts"`
+	Rx             int64       `json:"rx"`
+	RxBytes        int64       `json:"rxbytes"`
+	RxErrs         int64       `json:"rxerrs"`
+	Connects       int64       `json:"connects"`
+	Disconnects    int64       `json:"disconnects"`
+	IntLatency     WindowStats `json:"int_latency"`
+	OutbufLatency  WindowStats `json:"outbuf_latency"`
+	RTT            WindowStats `json:"rtt"`
+}
+
+// Up reports whether the broker connection is established.
+func (b BrokerStats) Up() bool {
+	return b.State == "UP"
+}
+
+type WindowStats struct {
+	Min    int64 `json:"min"`
+	Max    int64 `json:"max"`
+	Avg    int64 `json:"avg"`
+	Sum    int64 `json:"sum"`
+	Count  int64 `json:"cnt"`
+	StdDev int64 `json:"stddev"`
+	P50    int64 `json:"p50"`
+	P75    int64 `json:"p75"`
+	P90    int64 `json:"p90"`
+	P95    int64 `json:"p95"`
+	P99    int64 `json:"p99"`
+	P9999  int64 `json:"p99_99"`
+}
+
+type TopicStats struct {
+	Topic       string                    `json:"topic"`
+	Age         int64                     `json:"age"`
+	MetadataAge int64                     `json:"metadata_age"`
+	Partitions  map[string]PartitionStats `json:"partitions"`
+}
+
+type PartitionStats struct {
+	Partition     int32 `json:"partition"`
+	Broker        int32 `json:"broker"`
+	Leader        int32 `json:"leader"`
+	MsgqCount     int   `json:"msgq_cnt"`
+	MsgqBytes     int64 `json:"msgq_bytes"`
+	XmitMsgqCount int   `json:"xmit_msgq_cnt"`
+	XmitMsgqBytes int64 `json:"xmit_msgq_bytes"`
+	MsgsInflight  int   `json:"msgs_inflight"`
+	TxMsgs        int64 `json:"txmsgs"`
+	TxBytes       int64 `json:"txbytes"`
+	Msgs          int64 `json:"msgs"`
+	HiOffset      int64 `json:"hi_offset"`
+	LsOffset      int64 `json:"ls_offset"`
+	ConsumerLag   int64 `json:"consumer_lag"`
+}
+
+// Lag is the number of messages produced to the partition that have not
+// been acknowledged yet.
+func (p PartitionStats) Lag() int {
+	return p.MsgqCount + p.XmitMsgqCount + p.MsgsInflight
+}
+
+func ParseStats(data []byte) (Stats, error) {
+	var stats Stats
+	err := json.Unmarshal(data, &stats)
+	return stats, err
+}
+
+// Collected returns the wall-clock time at which librdkafka emitted the
+// statistics.
+func (s Stats) Collected() time.Time {
+	return time.Unix(s.Time, 0)
+}
@@ -0,0 +1,74 @@
+package kafka
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStats(t *testing.T) {
+	data, err := os.ReadFile("testdata/stats.json")
+	require.NoError(t, err)
+
+	stats, err := ParseStats(data)
+	require.NoError(t, err)
+
+	assert.Equal(t, "producer", stats.Type)
+	assert.Equal(t, 12, stats.MsgCount)
+	assert.Equal(t, int64(4300), stats.TxMsgs)
+	assert.Equal(t, time.Unix(1727000000, 0), stats.Collected())
+
+	require.Len(t, stats.Brokers, 2)
+	up := stats.Brokers["kafka-1:9092/1"]
+	assert.True(t, up.Up())
+	assert.Equal(t, int32(1), up.NodeID)
+	assert.Equal(t, int64(4500), up.RTT.Avg)
+	assert.Equal(t, int64(21000), up.RTT.P99)
+	assert.Equal(t, int64(2), up.TxRetries)
+
+	down := stats.Brokers["kafka-2:9092/2"]
+	assert.False(t, down.Up())
+	assert.Equal(t, int64(3), down.ReqTimeouts)
+
+	partition := stats.Topics["audit_events"].Partitions["0"]
+	assert.Equal(t, 6, partition.Lag())
+	assert.Equal(t, int64(2150), partition.TxMsgs)
+}
+
+func TestParseStats_Invalid(t *testing.T) {
+	_, err := ParseStats([]byte("{"))
+	assert.Error(t, err)
+}
+
+func TestProducer_HandleStats(t *testing.T) {
+	data, err := os.ReadFile("testdata/stats.json")
+	require.NoError(t, err)
+
+	var received []Stats
+	p := &Producer{config: &Config{OnStats: func(s Stats) { received = append(received, s) }}}
+
+	_, ok := p.Stats()
+	assert.False(t, ok)
+
+	p.handleStats("not json")
+	assert.Empty(t, received)
+
+	p.handleStats(string(data))
+	stats, ok := p.Stats()
+	require.True(t, ok)
+	assert.Equal(t, "audit-sdk", stats.ClientID)
+	require.Len(t, received, 1)
+}
+
+func TestBuildKafkaConfig_StatsInterval(t *testing.T) {
+	kafkaConfig := buildKafkaConfig(&Config{Brokers: []string{"localhost:9092"}})
+	interval, _ := kafkaConfig.Get("statistics.interval.ms", nil)
+	assert.Nil(t, interval)
+
+	kafkaConfig = buildKafkaConfig(&Config{Brokers: []string{"localhost:9092"}, StatsInterval: 15 * time.Second})
+	interval, _ = kafkaConfig.Get("statistics.interval.ms", 0)
+	assert.Equal(t, 15000, interval)
+}
@@ -0,0 +1,97 @@
+package kafka
+
+import (
+	"context"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+)
+
+// Result is the outcome of one message written by ProduceSync. Enqueued is
+// false when the message never reached the producer queue.
+type Result struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	Enqueued  bool
+	Latency   time.Duration
+	Err       error
+}
+
+// syncMessage is the Opaque of messages produced by ProduceSync. Their
+// delivery reports go to done instead of OnDelivery.
+type syncMessage struct {
+	enqueued time.Time
+	done     chan *kafka.Message
+}
+
+// ProduceSync writes value to every topic and waits for the delivery report
+// of each message, or until ctx is done. In transactional mode the messages
+// are committed together in a transaction of their own.
+func (p *Producer) ProduceSync(ctx context.Context, topics []string, key, value []byte) []Result {
+	msgs := newMessages(topics, key, value)
+
+	if p.transactor != nil {
+		outcomes := p.transactor.produceSync(ctx, msgs)
+		results := make([]Result, 0, len(outcomes))
+		for _, o := range outcomes {
+			result := newResult(o.msg, o.err)
+			result.Enqueued, result.Latency = o.enqueued, o.latency
+			results = append(results, result)
+		}
+		return results
+	}
+
+	results := make([]Result, len(msgs))
+	pending := make([]*syncMessage, len(msgs))
+	for i, msg := range msgs {
+		sm := &syncMessage{enqueued: time.Now(), done: make(chan *kafka.Message, 1)}
+		msg.Opaque = sm
+		if err := p.enqueue(msg); err != nil {
+			results[i] = newResult(msg, err)
+			continue
+		}
+		pending[i] = sm
+	}
+
+	for i, sm := range pending {
+		if sm == nil {
+			continue
+		}
+		select {
+		case msg := <-sm.done:
+			results[i] = newResult(msg, msg.TopicPartition.Error)
+			results[i].Latency = time.Since(sm.enqueued)
+		case <-ctx.Done():
+			results[i] = newResult(msgs[i], ctx.Err())
+		}
+		results[i].Enqueued = true
+	}
+
+	return results
+}
+
+func newMessages(topics []string, key, value []byte) []*kafka.Message {
+	msgs := make([]*kafka.Message, 0, len(topics))
+	for _, topic := range topics {
+		t := topic
+		msgs = append(msgs, &kafka.Message{
+			TopicPartition: kafka.TopicPartition{Topic: &t, Partition: kafka.PartitionAny},
+			Key:            key,
+			Value:          value,
+		})
+	}
+	return msgs
+}
+
+func newResult(msg *kafka.Message, err error) Result {
+	result := Result{
+		Partition: msg.TopicPartition.Partition,
+		Offset:    int64(msg.TopicPartition.Offset),
+		Err:       err,
+	}
+	if msg.TopicPartition.Topic != nil {
+		result.Topic = *msg.TopicPartition.Topic
+	}
+	return result
+}
@@ -0,0 +1,293 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+)
+
+const (
+	// MaxIdempotentInFlight is the highest max.in.flight.requests.per.connection
+	// that still guarantees ordering with enable.idempotence.
+	MaxIdempotentInFlight = 5
+
+	DefaultTransactionTimeout = 30 * time.Second
+
+	// commitRetries is how often a commit failing with a retriable error is
+	// retried, starting commitBackoff apart and doubling each time.
+	commitRetries = 5
+	commitBackoff = 100 * time.Millisecond
+)
+
+type transactional interface {
+	BeginTransaction() error
+	CommitTransaction(ctx context.Context) error
+	AbortTransaction(ctx context.Context) error
+	Produce(msg *kafka.Message, deliveryChan chan kafka.Event) error
+	Len() int
+}
+
+// transactor writes the messages of each ProduceAsync call inside a Kafka
+// transaction, so that either all of them become visible to read_committed
+// consumers or none do. Transactions are committed every batchSize messages
+// or batchTimeout, and delivery reports are held back until the outcome of
+// the transaction is known.
+type transactor struct {
+	producer     transactional
+	batchSize    int
+	batchTimeout time.Duration
+	capacity     int
+	timeout      time.Duration
+	backoff      time.Duration
+	retriable    func(error) bool
+	report       func(msg *kafka.Message, err error, enqueued bool, latency time.Duration)
+
+	mu       sync.Mutex
+	current  *txn
+	deferred []func()
+
+	closed chan struct{}
+	wg     sync.WaitGroup
+}
+
+type txn struct {
+	started time.Time
+	count   int
+	pending sync.WaitGroup
+
+	mu         sync.Mutex
+	deliveries []txnDelivery
+}
+
+type outcome struct {
+	msg      *kafka.Message
+	err      error
+	enqueued bool
+	latency  time.Duration
+}
+
+type txnDelivery struct {
+	msg     *kafka.Message
+	latency time.Duration
+}
+
+// txnMessage is the Opaque of messages produced inside a transaction.
+type txnMessage struct {
+	enqueued time.Time
+	txn      *txn
+	opaque   interface{}
+}
+
+func (t *txn) delivered(msg *kafka.Message, latency time.Duration) {
+	t.mu.Lock()
+	t.deliveries = append(t.deliveries, txnDelivery{msg: msg, latency: latency})
+	t.mu.Unlock()
+	t.pending.Done()
+}
+
+func newTransactor(producer transactional, cfg *Config, report func(*kafka.Message, error, bool, time.Duration)) *transactor {
+	t := &transactor{
+		producer:     producer,
+		batchSize:    cfg.BatchSize,
+		batchTimeout: cfg.BatchTimeout,
+		capacity:     cfg.QueueMaxMessages,
+		timeout:      cfg.TransactionTimeout,
+		backoff:      commitBackoff,
+		retriable:    isRetriable,
+		report:       report,
+		closed:       make(chan struct{}),
+	}
+
+	if t.batchTimeout > 0 {
+		t.wg.Add(1)
+		go t.commitLoop()
+	}
+
+	return t
+}
+
+func (t *transactor) produce(msgs []*kafka.Message) {
+	t.mu.Lock()
+	defer t.unlock()
+
+	// Make room up front: a queue-full error halfway through would leave
+	// only some of the messages in the transaction.
+	if t.current != nil && t.capacity > 0 && t.producer.Len()+len(msgs) > t.capacity {
+		t.reportLocked(t.commitLocked(context.Background()))
+	}
+
+	if t.current == nil {
+		if err := t.producer.BeginTransaction(); err != nil {
+			t.reportLocked(unsentOutcomes(msgs, err))
+			return
+		}
+		t.current = &txn{started: time.Now()}
+	}
+
+	if outcomes := t.appendLocked(msgs); outcomes != nil {
+		t.reportLocked(outcomes)
+		return
+	}
+
+	if t.batchSize > 0 && t.current.count >= t.batchSize {
+		t.reportLocked(t.commitLocked(context.Background()))
+	}
+}
+
+// produceSync writes msgs in a transaction of their own and returns their
+// outcomes once it has been committed or aborted. Any open batch is
+// committed first.
+func (t *transactor) produceSync(ctx context.Context, msgs []*kafka.Message) []outcome {
+	t.mu.Lock()
+	defer t.unlock()
+
+	if t.current != nil {
+		t.reportLocked(t.commitLocked(context.Background()))
+	}
+
+	if err := t.producer.BeginTransaction(); err != nil {
+		return unsentOutcomes(msgs, err)
+	}
+	t.current = &txn{started: time.Now()}
+
+	if outcomes := t.appendLocked(msgs); outcomes != nil {
+		return outcomes
+	}
+	return t.commitLocked(ctx)
+}
+
+// appendLocked produces msgs into the current transaction. If one of them
+// fails, the transaction is aborted and its outcomes are returned.
+func (t *transactor) appendLocked(msgs []*kafka.Message) []outcome {
+	for i, msg := range msgs {
+		msg.Opaque = &txnMessage{enqueued: time.Now(), txn: t.current, opaque: opaqueOf(msg)}
+		t.current.pending.Add(1)
+		if err := t.producer.Produce(msg, nil); err != nil {
+			t.current.pending.Done()
+			return t.abortLocked(err, msgs[i:])
+		}
+		t.current.count++
+	}
+	return nil
+}
+
+func (t *transactor) commitLoop() {
+	defer t.wg.Done()
+
+	ticker := time.NewTicker(t.batchTimeout)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.closed:
+			return
+		case <-ticker.C:
+			t.mu.Lock()
+			if t.current != nil && time.Since(t.current.started) >= t.batchTimeout {
+				t.reportLocked(t.commitLocked(context.Background()))
+			}
+			t.unlock()
+		}
+	}
+}
+
+func (t *transactor) commitLocked(ctx context.Context) []outcome {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	err := t.producer.CommitTransaction(ctx)
+	backoff := t.backoff
+	for attempt := 0; err != nil && attempt < commitRetries && t.retriable(err); attempt++ {
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return t.abortLocked(err, nil)
+		case <-timer.C:
+		}
+		backoff *= 2
+		err = t.producer.CommitTransaction(ctx)
+	}
+
+	if err != nil {
+		return t.abortLocked(err, nil)
+	}
+
+	return t.finish(nil, nil)
+}
+
+// abortLocked aborts the current transaction and reports every message in
+// it as failed with cause, along with unsent, the messages that never made
+// it into the transaction.
+func (t *transactor) abortLocked(cause error, unsent []*kafka.Message) []outcome {
+	ctx, cancel := context.WithTimeout(context.Background(), t.timeout)
+	defer cancel()
+	_ = t.producer.AbortTransaction(ctx)
+
+	return t.finish(cause, unsent)
+}
+
+// finish waits for the delivery reports of the current transaction and
+// returns the outcome of each message. A non-nil err overrides the
+// individual delivery errors.
+func (t *transactor) finish(err error, unsent []*kafka.Message) []outcome {
+	current := t.current
+	t.current = nil
+
+	current.pending.Wait()
+
+	outcomes := make([]outcome, 0, len(current.deliveries)+len(unsent))
+	for _, d := range current.deliveries {
+		deliveryErr := err
+		if deliveryErr == nil {
+			deliveryErr = d.msg.TopicPartition.Error
+		}
+		outcomes = append(outcomes, outcome{msg: d.msg, err: deliveryErr, enqueued: true, latency: d.latency})
+	}
+	return append(outcomes, unsentOutcomes(unsent, err)...)
+}
+
+func isRetriable(err error) bool {
+	var kerr kafka.Error
+	return errors.As(err, &kerr) && kerr.IsRetriable()
+}
+
+func unsentOutcomes(msgs []*kafka.Message, err error) []outcome {
+	outcomes := make([]outcome, 0, len(msgs))
+	for _, msg := range msgs {
+		outcomes = append(outcomes, outcome{msg: msg, err: err})
+	}
+	return outcomes
+}
+
+// reportLocked queues reports until mu is released, since the delivery
+// callback may produce again, for example to a dead-letter topic.
+func (t *transactor) reportLocked(outcomes []outcome) {
+	for _, o := range outcomes {
+		t.deferred = append(t.deferred, func() { t.report(o.msg, o.err, o.enqueued, o.latency) })
+	}
+}
+
+func (t *transactor) unlock() {
+	deferred := t.deferred
+	t.deferred = nil
+	t.mu.Unlock()
+
+	for _, fn := range deferred {
+		fn()
+	}
+}
+
+func (t *transactor) close() {
+	close(t.closed)
+	t.wg.Wait()
+
+	t.mu.Lock()
+	defer t.unlock()
+	if t.current != nil {
+		t.reportLocked(t.commitLocked(context.Background()))
+	}
+}
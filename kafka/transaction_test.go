@@ -0,0 +1,235 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTxnProducer delivers the messages of a transaction when it is
+// committed or aborted, as librdkafka flushes or purges them.
+type fakeTxnProducer struct {
+	calls      []string
+	queued     []*kafka.Message
+	produceErr func(*kafka.Message) error
+	commitErr  error
+}
+
+func (p *fakeTxnProducer) BeginTransaction() error {
+	p.calls = append(p.calls, "begin")
+	return nil
+}
+
+func (p *fakeTxnProducer) CommitTransaction(ctx context.Context) error {
+	p.calls = append(p.calls, "commit")
+	if p.commitErr != nil {
+		return p.commitErr
+	}
+	p.deliver(nil)
+	return nil
+}
+
+func (p *fakeTxnProducer) AbortTransaction(ctx context.Context) error {
+	p.calls = append(p.calls, "abort")
+	p.deliver(kafka.NewError(kafka.ErrPurgeQueue, "Local: Purged in queue", false))
+	return nil
+}
+
+func (p *fakeTxnProducer) Produce(msg *kafka.Message, deliveryChan chan kafka.Event) error {
+	if p.produceErr != nil {
+		if err := p.produceErr(msg); err != nil {
+			return err
+		}
+	}
+	p.queued = append(p.queued, msg)
+	return nil
+}
+
+func (p *fakeTxnProducer) Len() int { return len(p.queued) }
+
+func (p *fakeTxnProducer) deliver(err error) {
+	for _, msg := range p.queued {
+		msg.TopicPartition.Error = err
+		opaque := msg.Opaque.(*txnMessage)
+		opaque.txn.delivered(msg, 0)
+	}
+	p.queued = nil
+}
+
+type txnReport struct {
+	value    string
+	topic    string
+	err      error
+	enqueued bool
+}
+
+func newTestTransactor(producer *fakeTxnProducer, cfg *Config) (*transactor, *[]txnReport) {
+	reports := &[]txnReport{}
+	if cfg.TransactionTimeout == 0 {
+		cfg.TransactionTimeout = DefaultTransactionTimeout
+	}
+	t := newTransactor(producer, cfg, func(msg *kafka.Message, err error, enqueued bool, _ time.Duration) {
+		*reports = append(*reports, txnReport{string(msg.Value), *msg.TopicPartition.Topic, err, enqueued})
+	})
+	return t, reports
+}
+
+func eventMessages(value string) []*kafka.Message {
+	events, logs := "events", "logs"
+	return []*kafka.Message{
+		{TopicPartition: kafka.TopicPartition{Topic: &events}, Value: []byte(value)},
+		{TopicPartition: kafka.TopicPartition{Topic: &logs}, Value: []byte(value)},
+	}
+}
+
+func TestTransactor_CommitsBatches(t *testing.T) {
+	producer := &fakeTxnProducer{}
+	tx, reports := newTestTransactor(producer, &Config{BatchSize: 4})
+
+	tx.produce(eventMessages("a"))
+	assert.Empty(t, *reports, "reports are held until the transaction commits")
+
+	tx.produce(eventMessages("b"))
+	assert.Equal(t, []string{"begin", "commit"}, producer.calls)
+	require.Len(t, *reports, 4)
+	for _, r := range *reports {
+		assert.NoError(t, r.err)
+		assert.True(t, r.enqueued)
+	}
+
+	tx.produce(eventMessages("c"))
+	tx.close()
+	assert.Equal(t, []string{"begin", "commit", "begin", "commit"}, producer.calls)
+	assert.Len(t, *reports, 6)
+}
+
+func TestTransactor_AbortsOnProduceError(t *testing.T) {
+	queueErr := errors.New("Local: Unknown topic")
+	producer := &fakeTxnProducer{produceErr: func(msg *kafka.Message) error {
+		if *msg.TopicPartition.Topic == "logs" && string(msg.Value) == "b" {
+			return queueErr
+		}
+		return nil
+	}}
+	tx, reports := newTestTransactor(producer, &Config{BatchSize: 100})
+
+	tx.produce(eventMessages("a"))
+	tx.produce(eventMessages("b"))
+
+	assert.Equal(t, []string{"begin", "abort"}, producer.calls)
+	assert.Equal(t, []txnReport{
+		{"a", "events", queueErr, true},
+		{"a", "logs", queueErr, true},
+		{"b", "events", queueErr, true},
+		{"b", "logs", queueErr, false},
+	}, *reports)
+}
+
+func TestTransactor_AbortsOnCommitError(t *testing.T) {
+	commitErr := kafka.NewError(kafka.ErrInvalidTxnState, "invalid transaction state", false)
+	producer := &fakeTxnProducer{commitErr: commitErr}
+	tx, reports := newTestTransactor(producer, &Config{BatchSize: 2})
+
+	tx.produce(eventMessages("a"))
+
+	assert.Equal(t, []string{"begin", "commit", "abort"}, producer.calls)
+	require.Len(t, *reports, 2)
+	assert.Equal(t, commitErr, (*reports)[0].err)
+}
+
+func TestTransactor_RetriesCommitWithLimit(t *testing.T) {
+	commitErr := kafka.NewError(kafka.ErrTimedOut, "timed out", false)
+	producer := &fakeTxnProducer{commitErr: commitErr}
+	tx, reports := newTestTransactor(producer, &Config{BatchSize: 2})
+	tx.backoff = time.Millisecond
+	tx.retriable = func(error) bool { return true }
+
+	tx.produce(eventMessages("a"))
+
+	commits := 0
+	for _, call := range producer.calls {
+		if call == "commit" {
+			commits++
+		}
+	}
+	assert.Equal(t, commitRetries+1, commits)
+	assert.Equal(t, "abort", producer.calls[len(producer.calls)-1])
+	require.Len(t, *reports, 2)
+	assert.Equal(t, commitErr, (*reports)[0].err)
+}
+
+func TestTransactor_CommitsBeforeQueueFills(t *testing.T) {
+	producer := &fakeTxnProducer{}
+	tx, reports := newTestTransactor(producer, &Config{BatchSize: 100, QueueMaxMessages: 3})
+
+	tx.produce(eventMessages("a"))
+	tx.produce(eventMessages("b"))
+
+	assert.Equal(t, []string{"begin", "commit", "begin"}, producer.calls)
+	assert.Len(t, *reports, 2)
+	tx.close()
+}
+
+func TestTransactor_ProduceSync(t *testing.T) {
+	producer := &fakeTxnProducer{}
+	tx, reports := newTestTransactor(producer, &Config{BatchSize: 100})
+
+	tx.produce(eventMessages("a"))
+	outcomes := tx.produceSync(context.Background(), eventMessages("b"))
+
+	// The open batch is committed first so that "b" gets its own transaction.
+	assert.Equal(t, []string{"begin", "commit", "begin", "commit"}, producer.calls)
+	assert.Len(t, *reports, 2)
+	require.Len(t, outcomes, 2)
+	for _, o := range outcomes {
+		assert.Equal(t, "b", string(o.msg.Value))
+		assert.NoError(t, o.err)
+		assert.True(t, o.enqueued)
+	}
+}
+
+func TestTransactor_ProduceSync_Abort(t *testing.T) {
+	commitErr := kafka.NewError(kafka.ErrInvalidTxnState, "invalid transaction state", false)
+	producer := &fakeTxnProducer{commitErr: commitErr}
+	tx, reports := newTestTransactor(producer, &Config{})
+
+	outcomes := tx.produceSync(context.Background(), eventMessages("a"))
+
+	assert.Empty(t, *reports)
+	require.Len(t, outcomes, 2)
+	assert.Equal(t, commitErr, outcomes[0].err)
+	assert.Equal(t, commitErr, outcomes[1].err)
+}
+
+func TestBuildKafkaConfig_Transactional(t *testing.T) {
+	kafkaConfig := buildKafkaConfig(&Config{
+		Brokers:            []string{"localhost:9092"},
+		MaxInFlight:        5,
+		TransactionalID:    "audit-1",
+		TransactionTimeout: 30 * time.Second,
+	})
+
+	idempotence, _ := kafkaConfig.Get("enable.idempotence", false)
+	assert.Equal(t, true, idempotence)
+
+	inFlight, _ := kafkaConfig.Get("max.in.flight.requests.per.connection", 0)
+	assert.Equal(t, 5, inFlight)
+
+	transactionalID, _ := kafkaConfig.Get("transactional.id", "")
+	assert.Equal(t, "audit-1", transactionalID)
+
+	timeout, _ := kafkaConfig.Get("transaction.timeout.ms", 0)
+	assert.Equal(t, 30000, timeout)
+}
+
+func TestBuildKafkaConfig_NotIdempotentByDefault(t *testing.T) {
+	kafkaConfig := buildKafkaConfig(&Config{Brokers: []string{"localhost:9092"}})
+
+	_, ok := (*kafkaConfig)["enable.idempotence"]
+	assert.False(t, ok)
+}
@@ -0,0 +1,188 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+)
+
+const (
+	merkleLeafPrefix = 0x00
+	merkleNodePrefix = 0x01
+)
+
+type MerkleTree struct {
+	batch  string
+	ids    []string
+	index  map[string]int
+	levels [][][]byte
+}
+
+type InclusionProof struct {
+	EventID   string      `json:"event_id"`
+	BatchID   string      `json:"batch_id"`
+	LeafIndex int         `json:"leaf_index"`
+	LeafCount int         `json:"leaf_count"`
+	Path      []ProofStep `json:"path"`
+}
+
+type ProofStep struct {
+	Hash string `json:"hash"`
+	Left bool   `json:"left"`
+}
+
+// BuildMerkleTree builds the tree committed to by a checkpoint from the events
+// of its batch. Events may be given in any order; leaves are placed by their
+// batch index, which must be contiguous from zero.
+func BuildMerkleTree(events []Event) (*MerkleTree, error) {
+	if len(events) == 0 {
+		return nil, fmt.Errorf("%w: no events", ErrInvalidBatch)
+	}
+
+	ordered := append([]Event(nil), events...)
+	for _, event := range ordered {
+		if event.Batch == nil {
+			return nil, fmt.Errorf("%w: event %s has no batch reference", ErrInvalidBatch, event.ID)
+		}
+		if event.Batch.ID != ordered[0].Batch.ID {
+			return nil, fmt.Errorf("%w: events belong to different batches", ErrInvalidBatch)
+		}
+	}
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].Batch.Index < ordered[j].Batch.Index })
+
+	ids := make([]string, len(ordered))
+	leaves := make([][]byte, len(ordered))
+	for i, event := range ordered {
+		if event.Batch.Index != i {
+			return nil, fmt.Errorf("%w: missing or duplicate index %d", ErrInvalidBatch, i)
+		}
+		leaf, err := merkleLeaf(event)
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = event.ID
+		leaves[i] = leaf
+	}
+
+	return newMerkleTree(ordered[0].Batch.ID, ids, leaves), nil
+}
+
+func newMerkleTree(batch string, ids []string, leaves [][]byte) *MerkleTree {
+	index := make(map[string]int, len(ids))
+	for i, id := range ids {
+		index[id] = i
+	}
+
+	levels := [][][]byte{leaves}
+	for level := leaves; len(level) > 1; {
+		next := make([][]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			next = append(next, merkleNode(level[i], level[i+1]))
+		}
+		levels = append(levels, next)
+		level = next
+	}
+
+	return &MerkleTree{batch: batch, ids: ids, index: index, levels: levels}
+}
+
+func (t *MerkleTree) Root() string {
+	top := t.levels[len(t.levels)-1]
+	if len(top) == 0 {
+		return ""
+	}
+	return hex.EncodeToString(top[0])
+}
+
+func (t *MerkleTree) Count() int {
+	return len(t.ids)
+}
+
+func (t *MerkleTree) Prove(eventID string) (*InclusionProof, error) {
+	idx, ok := t.index[eventID]
+	if !ok {
+		return nil, fmt.Errorf("%w: event %s is not in the tree", ErrInclusionProof, eventID)
+	}
+
+	proof := &InclusionProof{
+		EventID:   eventID,
+		BatchID:   t.batch,
+		LeafIndex: idx,
+		LeafCount: len(t.ids),
+	}
+
+	for _, level := range t.levels[:len(t.levels)-1] {
+		sibling := idx ^ 1
+		if sibling < len(level) {
+			proof.Path = append(proof.Path, ProofStep{
+				Hash: hex.EncodeToString(level[sibling]),
+				Left: sibling < idx,
+			})
+		}
+		idx /= 2
+	}
+
+	return proof, nil
+}
+
+func VerifyInclusion(event Event, proof *InclusionProof, root string) error {
+	if proof == nil || event.ID != proof.EventID {
+		return fmt.Errorf("%w: proof does not refer to event %s", ErrInclusionProof, event.ID)
+	}
+	if event.Batch == nil || event.Batch.ID != proof.BatchID || event.Batch.Index != proof.LeafIndex {
+		return fmt.Errorf("%w: leaf index does not match the event batch reference", ErrInclusionProof)
+	}
+
+	node, err := merkleLeaf(event)
+	if err != nil {
+		return err
+	}
+
+	for _, step := range proof.Path {
+		sibling, err := hex.DecodeString(step.Hash)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInclusionProof, err)
+		}
+		if step.Left {
+			node = merkleNode(sibling, node)
+		} else {
+			node = merkleNode(node, sibling)
+		}
+	}
+
+	expected, err := hex.DecodeString(root)
+	if err != nil || !bytes.Equal(node, expected) {
+		return fmt.Errorf("%w: root mismatch", ErrInclusionProof)
+	}
+	return nil
+}
+
+func merkleLeaf(event Event) ([]byte, error) {
+	hash, err := EventHash(event)
+	if err != nil {
+		return nil, err
+	}
+	digest, err := hex.DecodeString(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	h := sha256.New()
+	h.Write([]byte{merkleLeafPrefix})
+	h.Write(digest)
+	return h.Sum(nil), nil
+}
+
+func merkleNode(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{merkleNodePrefix})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
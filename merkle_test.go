@@ -0,0 +1,100 @@
+package audit
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func batchEvents(n int) []Event {
+	events := make([]Event, n)
+	for i := range events {
+		events[i] = Event{
+			ID:     fmt.Sprintf("evt-%d", i),
+			TeamID: "team-1",
+			Event:  EventInfo{Type: "test.event"},
+			Batch:  &BatchRef{ID: "batch-1", Index: i},
+		}
+	}
+	return events
+}
+
+func TestMerkleTree_ProvesEveryLeaf(t *testing.T) {
+	for _, n := range []int{1, 2, 3, 4, 5, 7, 8, 13} {
+		t.Run(fmt.Sprintf("%d leaves", n), func(t *testing.T) {
+			events := batchEvents(n)
+
+			tree, err := BuildMerkleTree(events)
+			require.NoError(t, err)
+			assert.Equal(t, n, tree.Count())
+
+			for _, event := range events {
+				proof, err := tree.Prove(event.ID)
+				require.NoError(t, err)
+				assert.Equal(t, "batch-1", proof.BatchID)
+				assert.Equal(t, n, proof.LeafCount)
+				assert.NoError(t, VerifyInclusion(event, proof, tree.Root()))
+			}
+		})
+	}
+}
+
+func TestMerkleTree_OrderIndependent(t *testing.T) {
+	events := batchEvents(5)
+	shuffled := []Event{events[3], events[0], events[4], events[2], events[1]}
+
+	a, err := BuildMerkleTree(events)
+	require.NoError(t, err)
+	b, err := BuildMerkleTree(shuffled)
+	require.NoError(t, err)
+
+	assert.Equal(t, a.Root(), b.Root())
+}
+
+func TestVerifyInclusion_Rejects(t *testing.T) {
+	events := batchEvents(4)
+	tree, err := BuildMerkleTree(events)
+	require.NoError(t, err)
+
+	proof, err := tree.Prove("evt-2")
+	require.NoError(t, err)
+
+	tampered := events[2]
+	tampered.Event.Description = "tampered"
+	assert.ErrorIs(t, VerifyInclusion(tampered, proof, tree.Root()), ErrInclusionProof)
+
+	assert.ErrorIs(t, VerifyInclusion(events[1], proof, tree.Root()), ErrInclusionProof)
+
+	other, err := BuildMerkleTree(batchEvents(5))
+	require.NoError(t, err)
+	assert.ErrorIs(t, VerifyInclusion(events[2], proof, other.Root()), ErrInclusionProof)
+
+	_, err = tree.Prove("missing")
+	assert.ErrorIs(t, err, ErrInclusionProof)
+}
+
+func TestBuildMerkleTree_InvalidBatch(t *testing.T) {
+	_, err := BuildMerkleTree(nil)
+	assert.ErrorIs(t, err, ErrInvalidBatch)
+
+	events := batchEvents(3)
+	_, err = BuildMerkleTree([]Event{events[0], events[2]})
+	assert.ErrorIs(t, err, ErrInvalidBatch)
+
+	events[1].Batch = &BatchRef{ID: "batch-2", Index: 1}
+	_, err = BuildMerkleTree(events)
+	assert.ErrorIs(t, err, ErrInvalidBatch)
+
+	events[1].Batch = nil
+	_, err = BuildMerkleTree(events)
+	assert.ErrorIs(t, err, ErrInvalidBatch)
+}
+
+func TestMerkleTree_Empty(t *testing.T) {
+	tree := newMerkleTree("batch-1", nil, nil)
+
+	assert.Equal(t, "", tree.Root())
+	assert.Equal(t, 0, tree.Count())
+}
@@ -0,0 +1,52 @@
+package audit
+
+import "time"
+
+// Observer receives notifications from the emit pipeline, typically to
+// export metrics. Implementations must be safe for concurrent use and must
+// not block: delivery notifications run on the producer's event loop.
+type Observer interface {
+	EventEmitted(event *Event)
+	EventRejected(event *Event, err error)
+	EncodeFailed(event *Event, err error)
+	ProduceFailed(topic string, err error)
+	Delivered(topic string, latency time.Duration, err error)
+	QueueChanged(depth int, inFlightBytes int64)
+	Dropped(topic string, reason DropReason)
+	SpoolChanged(depth int, bytes int64)
+}
+
+type nopObserver struct{}
+
+func (nopObserver) EventEmitted(*Event)                    {}
+func (nopObserver) EventRejected(*Event, error)            {}
+func (nopObserver) EncodeFailed(*Event, error)             {}
+func (nopObserver) ProduceFailed(string, error)            {}
+func (nopObserver) Delivered(string, time.Duration, error) {}
+func (nopObserver) QueueChanged(int, int64)                {}
+func (nopObserver) Dropped(string, DropReason)             {}
+func (nopObserver) SpoolChanged(int, int64)                {}
+
+type queueReporter interface {
+	Len() int
+	InFlightBytes() int64
+}
+
+func (c *client) observer() Observer {
+	if c.config.Observer == nil {
+		return nopObserver{}
+	}
+	return c.config.Observer
+}
+
+func (c *client) queueChanged() {
+	if c.config.Observer == nil {
+		return
+	}
+	if q, ok := c.producer.(queueReporter); ok {
+		c.config.Observer.QueueChanged(q.Len(), q.InFlightBytes())
+	}
+	if s, ok := c.producer.(spoolReporter); ok {
+		c.config.Observer.SpoolChanged(s.Spool())
+	}
+}
@@ -0,0 +1,114 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NeuralTrust/audit-sdk-go/kafka"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingObserver struct {
+	mu       sync.Mutex
+	calls    []string
+	depth    int
+	inFlight int64
+	spool    int
+}
+
+func (o *recordingObserver) record(call string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.calls = append(o.calls, call)
+}
+
+func (o *recordingObserver) EventEmitted(event *Event) { o.record("emitted:" + event.Event.Type) }
+func (o *recordingObserver) EventRejected(event *Event, err error) {
+	o.record("rejected:" + event.Event.Type)
+}
+func (o *recordingObserver) EncodeFailed(event *Event, err error) {
+	o.record("encode:" + event.Event.Type)
+}
+func (o *recordingObserver) ProduceFailed(topic string, err error) { o.record("produce:" + topic) }
+
+func (o *recordingObserver) Delivered(topic string, latency time.Duration, err error) {
+	if err != nil {
+		o.record("delivery_failed:" + topic)
+		return
+	}
+	o.record("delivered:" + topic)
+}
+
+func (o *recordingObserver) QueueChanged(depth int, inFlightBytes int64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.depth, o.inFlight = depth, inFlightBytes
+}
+
+func (o *recordingObserver) Dropped(topic string, reason DropReason) {
+	o.record("dropped:" + topic + ":" + string(reason))
+}
+
+func (o *recordingObserver) SpoolChanged(depth int, bytes int64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.spool = depth
+}
+
+type queueingProducer struct {
+	mockProducer
+}
+
+func (p *queueingProducer) Len() int { return len(p.producedMessages) }
+
+func (p *queueingProducer) InFlightBytes() int64 {
+	var total int64
+	for _, msg := range p.producedMessages {
+		total += int64(len(msg.value))
+	}
+	return total
+}
+
+func TestClient_Observer(t *testing.T) {
+	observer := &recordingObserver{}
+	producer := &queueingProducer{}
+	c := &client{
+		config: &Config{
+			Observer: observer,
+			Interceptors: []Interceptor{func(ctx context.Context, event *Event, next Next) error {
+				if event.Event.Type == "blocked" {
+					return errors.New("blocked")
+				}
+				return next(ctx, event)
+			}},
+		},
+		producer: producer,
+		topics:   []string{"events"},
+		logger:   testLogger(),
+	}
+
+	require.NoError(t, c.Emit(Event{TeamID: "team-1", Event: EventInfo{Type: "ok"}}))
+	assert.Error(t, c.Emit(Event{Event: EventInfo{Type: "invalid"}}))
+	assert.Error(t, c.Emit(Event{TeamID: "team-1", Event: EventInfo{Type: "blocked"}}))
+	assert.Error(t, c.Emit(Event{TeamID: "team-1", Event: EventInfo{Type: "encode"}, Metadata: &Metadata{"bad": make(chan int)}}))
+
+	c.handleDelivery(kafka.DeliveryReport{Topic: "events", Enqueued: true, Latency: time.Millisecond})
+	c.handleDelivery(kafka.DeliveryReport{Topic: "events", Enqueued: true, Err: errors.New("timeout")})
+	c.handleDelivery(kafka.DeliveryReport{Topic: "events", Err: errors.New("queue full")})
+
+	assert.Equal(t, []string{
+		"emitted:ok",
+		"rejected:invalid",
+		"rejected:blocked",
+		"encode:encode",
+		"delivered:events",
+		"delivery_failed:events",
+		"produce:events",
+	}, observer.calls)
+	assert.Equal(t, 1, observer.depth)
+	assert.Equal(t, producer.InFlightBytes(), observer.inFlight)
+}
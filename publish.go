@@ -0,0 +1,170 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/NeuralTrust/audit-sdk-go/kafka"
+)
+
+var ErrSyncUnsupported = errors.New("audit: producer does not support synchronous publishing")
+
+type TopicResult struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	Attempts  int
+	Err       error
+}
+
+// PublishResult reports the outcome of a synchronous publish for each of
+// the event's topics.
+type PublishResult struct {
+	EventID string
+	Topics  []TopicResult
+}
+
+func (r PublishResult) Failed() []TopicResult {
+	var failed []TopicResult
+	for _, t := range r.Topics {
+		if t.Err != nil {
+			failed = append(failed, t)
+		}
+	}
+	return failed
+}
+
+type PublishError struct {
+	Result PublishResult
+}
+
+func (e *PublishError) Error() string {
+	var legs []string
+	for _, t := range e.Result.Failed() {
+		legs = append(legs, fmt.Sprintf("%s after %d attempts: %v", t.Topic, t.Attempts, t.Err))
+	}
+	return fmt.Sprintf("audit: event %s was not published to %s", e.Result.EventID, strings.Join(legs, "; "))
+}
+
+func (e *PublishError) Unwrap() []error {
+	var errs []error
+	for _, t := range e.Result.Failed() {
+		errs = append(errs, t.Err)
+	}
+	return errs
+}
+
+// Publisher is implemented by clients that can publish synchronously.
+type Publisher interface {
+	Publish(ctx context.Context, event Event) (PublishResult, error)
+}
+
+var _ Publisher = (*client)(nil)
+
+type syncProducer interface {
+	ProduceSync(ctx context.Context, topics []string, key, value []byte) []kafka.Result
+}
+
+type publishKey struct{}
+
+// Publish emits event and waits until every topic has acknowledged it.
+// Topics that fail are retried on their own, up to RetryMax times with
+// exponential RetryBackoff, and the per-topic outcome is returned. When
+// some topic still fails, the error is a *PublishError.
+func (c *client) Publish(ctx context.Context, event Event) (PublishResult, error) {
+	result := &PublishResult{}
+	err := c.emitContext(context.WithValue(ctx, publishKey{}, result), event)
+	return *result, err
+}
+
+func (c *client) publishSync(ctx context.Context, event *Event, topics []string, data []byte, result *PublishResult) error {
+	producer, ok := c.producer.(syncProducer)
+	if !ok {
+		return ErrSyncUnsupported
+	}
+
+	start := time.Now()
+	result.EventID = event.ID
+	result.Topics = make([]TopicResult, len(topics))
+	index := make(map[string]int, len(topics))
+	for i, topic := range topics {
+		result.Topics[i].Topic = topic
+		index[topic] = i
+	}
+	enqueued := make([]bool, len(topics))
+
+	c.observer().EventEmitted(event)
+
+	pending := topics
+	backoff := c.config.RetryBackoff
+	for attempt := 1; ; attempt++ {
+		var failed []string
+		for _, r := range producer.ProduceSync(ctx, pending, []byte(event.TeamID), data) {
+			i := index[r.Topic]
+			result.Topics[i] = TopicResult{Topic: r.Topic, Partition: r.Partition, Offset: r.Offset, Attempts: attempt, Err: r.Err}
+			enqueued[i] = r.Enqueued
+
+			if r.Enqueued {
+				c.observer().Delivered(r.Topic, r.Latency, r.Err)
+			} else {
+				c.observer().ProduceFailed(r.Topic, r.Err)
+			}
+			c.deliveries.record(time.Now(), r.Err != nil)
+
+			if r.Err != nil {
+				failed = append(failed, r.Topic)
+			}
+		}
+		c.queueChanged()
+
+		if len(failed) == 0 || attempt > c.config.RetryMax || !sleepContext(ctx, backoff) {
+			break
+		}
+		backoff *= 2
+		pending = failed
+	}
+
+	failed := result.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+
+	for _, t := range failed {
+		c.handleError(*event, t.Err, "failed to publish audit event",
+			slog.String("topic", t.Topic),
+			slog.Int("attempts", t.Attempts),
+		)
+
+		reason := DeadLetterProduce
+		if enqueued[index[t.Topic]] {
+			reason = DeadLetterDelivery
+		}
+		c.deadLetterPublish(event, data, t, reason, start)
+	}
+
+	return &PublishError{Result: *result}
+}
+
+// partiallyPublished reports whether err is a PublishError for an event that
+// reached some of its topics. Consumers of those topics see the event, so it
+// still takes its place in the hash chain and checkpoint batch.
+func partiallyPublished(err error) bool {
+	var publishErr *PublishError
+	return errors.As(err, &publishErr) && len(publishErr.Result.Failed()) < len(publishErr.Result.Topics)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,184 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/NeuralTrust/audit-sdk-go/kafka"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// syncingProducer fails each topic with the queued errors, one per attempt,
+// and succeeds once they run out.
+type syncingProducer struct {
+	mockProducer
+	failures  map[string][]error
+	calls     [][]string
+	offset    int64
+	delivered map[string][][]byte
+}
+
+func (p *syncingProducer) ProduceSync(ctx context.Context, topics []string, key, value []byte) []kafka.Result {
+	p.calls = append(p.calls, topics)
+
+	var results []kafka.Result
+	for _, topic := range topics {
+		result := kafka.Result{Topic: topic, Enqueued: true, Latency: time.Millisecond}
+		if errs := p.failures[topic]; len(errs) > 0 {
+			result.Err = errs[0]
+			p.failures[topic] = errs[1:]
+		} else {
+			p.offset++
+			result.Offset = p.offset
+			if p.delivered == nil {
+				p.delivered = make(map[string][][]byte)
+			}
+			p.delivered[topic] = append(p.delivered[topic], value)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func testEvent() Event {
+	return Event{TeamID: "team-1", Event: EventInfo{Type: "test.event"}}
+}
+
+func TestClient_Publish(t *testing.T) {
+	producer := &syncingProducer{}
+	c := newTestClient(producer, &Config{RetryMax: 3, RetryBackoff: time.Millisecond}, "events", "logs")
+
+	result, err := c.Publish(context.Background(), testEvent())
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, result.EventID)
+	assert.Equal(t, []TopicResult{
+		{Topic: "events", Offset: 1, Attempts: 1},
+		{Topic: "logs", Offset: 2, Attempts: 1},
+	}, result.Topics)
+	assert.Empty(t, producer.producedMessages)
+}
+
+func TestClient_Publish_RetriesFailedLegOnly(t *testing.T) {
+	producer := &syncingProducer{failures: map[string][]error{
+		"logs": {errors.New("Local: Message timed out")},
+	}}
+	c := newTestClient(producer, &Config{RetryMax: 3, RetryBackoff: time.Millisecond}, "events", "logs")
+
+	result, err := c.Publish(context.Background(), testEvent())
+	require.NoError(t, err)
+
+	assert.Equal(t, [][]string{{"events", "logs"}, {"logs"}}, producer.calls)
+	assert.Equal(t, 1, result.Topics[0].Attempts)
+	assert.Equal(t, 2, result.Topics[1].Attempts)
+	assert.Empty(t, result.Failed())
+}
+
+func TestClient_Publish_ReportsPersistentFailure(t *testing.T) {
+	timeout := errors.New("Local: Message timed out")
+	producer := &syncingProducer{failures: map[string][]error{
+		"logs": {timeout, timeout, timeout, timeout, timeout},
+	}}
+	c := newTestClient(producer, &Config{
+		RetryMax:     3,
+		RetryBackoff: time.Millisecond,
+		DeadLetter:   &DeadLetterConfig{Enable: true, Topic: "dead"},
+	}, "events", "logs")
+
+	var errs []error
+	c.config.OnError = func(event Event, err error) { errs = append(errs, err) }
+
+	result, err := c.Publish(context.Background(), testEvent())
+
+	var publishErr *PublishError
+	require.ErrorAs(t, err, &publishErr)
+	assert.ErrorIs(t, err, timeout)
+	assert.Contains(t, err.Error(), "logs after 4 attempts")
+
+	assert.Len(t, producer.calls, 4)
+	require.Len(t, result.Failed(), 1)
+	assert.Equal(t, "logs", result.Failed()[0].Topic)
+	assert.NoError(t, result.Topics[0].Err)
+	assert.Len(t, errs, 1)
+
+	letters := deadLetters(t, &producer.mockProducer)
+	require.Len(t, letters, 1)
+	assert.Equal(t, DeadLetterDelivery, letters[0].Reason)
+	assert.Equal(t, "logs", letters[0].Topic)
+	assert.Equal(t, 4, letters[0].Attempts)
+}
+
+func TestClient_Publish_StopsOnContextDone(t *testing.T) {
+	timeout := errors.New("Local: Message timed out")
+	producer := &syncingProducer{failures: map[string][]error{
+		"events": {timeout, timeout, timeout, timeout},
+	}}
+	c := newTestClient(producer, &Config{RetryMax: 3, RetryBackoff: time.Hour}, "events", "logs")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := c.Publish(ctx, testEvent())
+	assert.ErrorIs(t, err, timeout)
+	assert.Len(t, producer.calls, 1)
+}
+
+func TestClient_SyncPublishMode(t *testing.T) {
+	producer := &syncingProducer{failures: map[string][]error{
+		"events": {errors.New("a"), errors.New("b"), errors.New("c"), errors.New("d")},
+	}}
+	c := newTestClient(producer, &Config{RetryMax: 3, RetryBackoff: time.Millisecond, SyncPublish: true}, "events", "logs")
+
+	var publishErr *PublishError
+	assert.ErrorAs(t, c.Emit(testEvent()), &publishErr)
+}
+
+func TestClient_Publish_Unsupported(t *testing.T) {
+	c := newTestClient(&mockProducer{}, &Config{RetryMax: 3, RetryBackoff: time.Millisecond}, "events", "logs")
+
+	_, err := c.Publish(context.Background(), testEvent())
+	assert.ErrorIs(t, err, ErrSyncUnsupported)
+}
+
+func TestClient_Publish_PartialFailureKeepsChain(t *testing.T) {
+	timeout := errors.New("Local: Message timed out")
+	producer := &syncingProducer{failures: map[string][]error{
+		"logs": {timeout, timeout, timeout, timeout},
+	}}
+	c := newTestClient(producer, &Config{
+		RetryMax:     3,
+		RetryBackoff: time.Millisecond,
+		HashChain:    &HashChainConfig{Enable: true, ProducerID: "producer-a"},
+		Checkpoint:   testCheckpointConfig(newTestEd25519Signer(t, "key-1")),
+	}, "events", "logs")
+
+	_, err := c.Publish(context.Background(), testEvent())
+	var publishErr *PublishError
+	require.ErrorAs(t, err, &publishErr)
+
+	_, err = c.Publish(context.Background(), testEvent())
+	require.NoError(t, err)
+
+	var events []Event
+	for _, data := range producer.delivered["events"] {
+		var event Event
+		require.NoError(t, json.Unmarshal(data, &event))
+		events = append(events, event)
+	}
+	require.Len(t, events, 2)
+	assert.Equal(t, uint64(2), events[1].Integrity.Sequence)
+	assert.Equal(t, 1, events[1].Batch.Index)
+	assert.True(t, VerifyChain(events).OK())
+
+	c.checkpoints.flush(time.Now().UTC(), true)
+	_, checkpoints := splitCheckpoints(t, &producer.mockProducer)
+	require.Len(t, checkpoints, 1)
+
+	tree, err := BuildMerkleTree(events)
+	require.NoError(t, err)
+	assert.Equal(t, checkpoints[0].Root, tree.Root())
+}
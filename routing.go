@@ -0,0 +1,203 @@
+package audit
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// maxTopicLength is the longest topic name Kafka accepts.
+const maxTopicLength = 249
+
+type RouteMode string
+
+const (
+	// RouteAdd sends matching events to the route's topic as well as the
+	// default topics.
+	RouteAdd RouteMode = "add"
+	// RouteReplace sends matching events to the route's topic instead of the
+	// default topics.
+	RouteReplace RouteMode = "replace"
+)
+
+// Route sends events matching all of its non-empty filters to Topic. Topic
+// may contain the placeholders {category}, {team_id}, {status} and {type},
+// which are filled from the event. Characters Kafka does not allow in topic
+// names are replaced with underscores.
+type Route struct {
+	Categories []string
+	Teams      []string
+	Statuses   []string
+	Topic      string
+	Mode       RouteMode
+}
+
+var placeholderPattern = regexp.MustCompile(`\{[^{}]*\}`)
+
+var placeholders = map[string]func(*Event) string{
+	"{category}": func(e *Event) string { return e.Event.Category },
+	"{team_id}":  func(e *Event) string { return e.TeamID },
+	"{status}":   func(e *Event) string { return e.Event.Status },
+	"{type}":     func(e *Event) string { return e.Event.Type },
+}
+
+func (r *Route) validate() error {
+	if r.Topic == "" {
+		return fmt.Errorf("%w: topic is required", ErrInvalidRoute)
+	}
+	switch r.Mode {
+	case "", RouteAdd, RouteReplace:
+	default:
+		return fmt.Errorf("%w: unknown mode %q", ErrInvalidRoute, r.Mode)
+	}
+	for _, p := range placeholderPattern.FindAllString(r.Topic, -1) {
+		if _, ok := placeholders[p]; !ok {
+			return fmt.Errorf("%w: unknown placeholder %s in %q", ErrInvalidRoute, p, r.Topic)
+		}
+	}
+	if !r.templated() && !validTopic(r.Topic) {
+		return fmt.Errorf("%w: invalid topic %q", ErrInvalidRoute, r.Topic)
+	}
+	return nil
+}
+
+func (r *Route) templated() bool {
+	return placeholderPattern.MatchString(r.Topic)
+}
+
+func (r *Route) matches(event *Event) bool {
+	return matchAny(r.Categories, event.Event.Category) &&
+		matchAny(r.Teams, event.TeamID) &&
+		matchAny(r.Statuses, event.Event.Status)
+}
+
+// render fills the placeholders of the route's topic. It returns false when
+// a placeholder is empty for event, since the topic would then be shared by
+// unrelated events.
+func (r *Route) render(event *Event) (string, bool) {
+	ok := true
+	topic := placeholderPattern.ReplaceAllStringFunc(r.Topic, func(p string) string {
+		value := sanitizeTopic(placeholders[p](event))
+		if value == "" {
+			ok = false
+		}
+		return value
+	})
+	return topic, ok && len(topic) <= maxTopicLength
+}
+
+func matchAny(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sanitizeTopic(value string) string {
+	return strings.Map(func(r rune) rune {
+		if isTopicRune(r) {
+			return r
+		}
+		return '_'
+	}, value)
+}
+
+func validTopic(topic string) bool {
+	if topic == "." || topic == ".." || len(topic) > maxTopicLength {
+		return false
+	}
+	for _, r := range topic {
+		if !isTopicRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isTopicRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '_' || r == '-'
+}
+
+// router resolves the topics of each event from the default topics and the
+// configured routes. Every matching route applies, in order.
+type router struct {
+	defaults []string
+	routes   []Route
+}
+
+func newRouter(defaults []string, routes []Route) *router {
+	return &router{defaults: defaults, routes: routes}
+}
+
+func (r *router) topics(event *Event) []string {
+	if r == nil {
+		return nil
+	}
+
+	var routed []string
+	replace := false
+	for i := range r.routes {
+		route := &r.routes[i]
+		if !route.matches(event) {
+			continue
+		}
+		topic, ok := route.render(event)
+		if !ok {
+			continue
+		}
+		routed = appendUnique(routed, topic)
+		if route.Mode == RouteReplace {
+			replace = true
+		}
+	}
+
+	if len(routed) == 0 {
+		return r.defaults
+	}
+
+	var topics []string
+	if !replace {
+		topics = append(topics, r.defaults...)
+	}
+	for _, topic := range routed {
+		topics = appendUnique(topics, topic)
+	}
+	return topics
+}
+
+// static returns the topics of routes without placeholders, which can be
+// created up front.
+func (r *router) static() []string {
+	if r == nil {
+		return nil
+	}
+
+	var topics []string
+	for i := range r.routes {
+		if !r.routes[i].templated() {
+			topics = appendUnique(topics, r.routes[i].Topic)
+		}
+	}
+	return topics
+}
+
+func appendUnique(topics []string, topic string) []string {
+	for _, t := range topics {
+		if t == topic {
+			return topics
+		}
+	}
+	return append(topics, topic)
+}
+
+func (c *client) eventTopics(event *Event) []string {
+	if c.router == nil {
+		return c.topics
+	}
+	return c.router.topics(event)
+}
@@ -0,0 +1,128 @@
+package audit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testRoutes = []Route{
+	{Categories: []string{"security"}, Topic: "audit_security"},
+	{Teams: []string{"acme"}, Topic: "audit.tenant.{team_id}", Mode: RouteReplace},
+	{Statuses: []string{"failure"}, Topic: "audit_alerts"},
+	{Categories: []string{"billing"}, Topic: "audit.{category}.{status}"},
+}
+
+func TestRouter_Topics(t *testing.T) {
+	r := newRouter([]string{"events", "logs"}, testRoutes)
+
+	tests := []struct {
+		name  string
+		event Event
+		want  []string
+	}{
+		{
+			name:  "no match",
+			event: Event{TeamID: "team-1", Event: EventInfo{Category: "auth", Status: "success"}},
+			want:  []string{"events", "logs"},
+		},
+		{
+			name:  "add",
+			event: Event{TeamID: "team-1", Event: EventInfo{Category: "security", Status: "success"}},
+			want:  []string{"events", "logs", "audit_security"},
+		},
+		{
+			name:  "replace",
+			event: Event{TeamID: "acme", Event: EventInfo{Category: "auth"}},
+			want:  []string{"audit.tenant.acme"},
+		},
+		{
+			name:  "every matching route applies",
+			event: Event{TeamID: "acme", Event: EventInfo{Category: "security", Status: "failure"}},
+			want:  []string{"audit_security", "audit.tenant.acme", "audit_alerts"},
+		},
+		{
+			name:  "template",
+			event: Event{TeamID: "team-1", Event: EventInfo{Category: "billing", Status: "success"}},
+			want:  []string{"events", "logs", "audit.billing.success"},
+		},
+		{
+			name:  "empty placeholder skips the route",
+			event: Event{TeamID: "team-1", Event: EventInfo{Category: "billing"}},
+			want:  []string{"events", "logs"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, r.topics(&tt.event))
+		})
+	}
+}
+
+func TestRoute_Render_Sanitizes(t *testing.T) {
+	route := Route{Topic: "audit.tenant.{team_id}"}
+
+	topic, ok := route.render(&Event{TeamID: "Acme Corp/EU"})
+	assert.True(t, ok)
+	assert.Equal(t, "audit.tenant.Acme_Corp_EU", topic)
+}
+
+func TestRoute_Validate(t *testing.T) {
+	tests := []struct {
+		name  string
+		route Route
+		valid bool
+	}{
+		{name: "static", route: Route{Topic: "audit_security"}, valid: true},
+		{name: "template", route: Route{Topic: "audit.{category}", Mode: RouteReplace}, valid: true},
+		{name: "no topic", route: Route{Categories: []string{"security"}}},
+		{name: "unknown mode", route: Route{Topic: "audit_security", Mode: "copy"}},
+		{name: "unknown placeholder", route: Route{Topic: "audit.{actor}"}},
+		{name: "invalid topic", route: Route{Topic: "audit security"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.route.validate()
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidRoute)
+			}
+		})
+	}
+}
+
+func TestRouter_Static(t *testing.T) {
+	r := newRouter([]string{"events", "logs"}, append(testRoutes, Route{Topic: "audit_security", Mode: RouteReplace}))
+
+	assert.Equal(t, []string{"audit_security", "audit_alerts"}, r.static())
+}
+
+func TestClient_Emit_Routes(t *testing.T) {
+	mock := &mockProducer{}
+	c := &client{
+		config:   &Config{},
+		producer: mock,
+		topics:   []string{"events", "logs"},
+		router:   newRouter([]string{"events", "logs"}, testRoutes),
+		logger:   testLogger(),
+	}
+
+	require.NoError(t, c.Emit(Event{TeamID: "acme", Event: EventInfo{Type: "user.login", Category: "security"}}))
+	require.NoError(t, c.Emit(Event{TeamID: "team-1", Event: EventInfo{Type: "user.login", Category: "auth"}}))
+
+	require.Len(t, mock.producedMessages, 2)
+	assert.Equal(t, []string{"audit_security", "audit.tenant.acme"}, mock.producedMessages[0].topics)
+	assert.Equal(t, []string{"events", "logs"}, mock.producedMessages[1].topics)
+	assert.Equal(t, []string{"events", "logs", "audit_security", "audit_alerts"}, c.healthTopics())
+}
+
+func TestConfig_Validate_Routes(t *testing.T) {
+	cfg := &Config{Brokers: []string{"localhost:9092"}, Routes: []Route{{Topic: "audit.{actor}"}}}
+	cfg.setDefaults()
+
+	assert.ErrorIs(t, cfg.validate(), ErrInvalidRoute)
+}
@@ -0,0 +1,145 @@
+package audit
+
+import (
+	"context"
+	"sync"
+)
+
+type scopeKey struct{}
+
+// Scope collects audit attributes over the lifetime of a request. Middleware
+// creates it with the request context; handlers add the actor, target and
+// metadata as they learn them, and EmitContext fills events from it.
+type Scope struct {
+	mu       sync.Mutex
+	teamID   string
+	actor    *Actor
+	target   *Target
+	context  Context
+	metadata Metadata
+}
+
+func NewScope(ctx context.Context, auditCtx Context) (context.Context, *Scope) {
+	scope := &Scope{context: auditCtx}
+	return context.WithValue(ctx, scopeKey{}, scope), scope
+}
+
+func ScopeFromContext(ctx context.Context) *Scope {
+	if ctx == nil {
+		return nil
+	}
+	scope, _ := ctx.Value(scopeKey{}).(*Scope)
+	return scope
+}
+
+func (s *Scope) SetTeamID(teamID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.teamID = teamID
+}
+
+func (s *Scope) TeamID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.teamID
+}
+
+func (s *Scope) SetActor(actor Actor) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.actor = &actor
+}
+
+func (s *Scope) Actor() *Actor {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.actor == nil {
+		return nil
+	}
+	actor := *s.actor
+	return &actor
+}
+
+func (s *Scope) SetTarget(target Target) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.target = &target
+}
+
+func (s *Scope) Target() *Target {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.target == nil {
+		return nil
+	}
+	target := *s.target
+	return &target
+}
+
+func (s *Scope) SetContext(auditCtx Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.context = auditCtx
+}
+
+func (s *Scope) Context() Context {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.context
+}
+
+func (s *Scope) AddMetadata(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.metadata == nil {
+		s.metadata = make(Metadata)
+	}
+	s.metadata[key] = value
+}
+
+func (s *Scope) Metadata() Metadata {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.metadata == nil {
+		return nil
+	}
+	metadata := make(Metadata, len(s.metadata))
+	for key, value := range s.metadata {
+		metadata[key] = value
+	}
+	return metadata
+}
+
+// Apply fills the fields the event leaves empty from the scope. Metadata is
+// merged, with keys set on the event taking precedence.
+func (s *Scope) Apply(event *Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if event.TeamID == "" {
+		event.TeamID = s.teamID
+	}
+	if event.Actor == nil && s.actor != nil {
+		actor := *s.actor
+		event.Actor = &actor
+	}
+	if event.Target.IsZero() && len(event.Targets) == 0 && s.target != nil {
+		event.Target = *s.target
+	}
+	if event.Context == nil && s.context != (Context{}) {
+		auditCtx := s.context
+		event.Context = &auditCtx
+	}
+	if len(s.metadata) > 0 {
+		metadata := make(Metadata, len(s.metadata))
+		for key, value := range s.metadata {
+			metadata[key] = value
+		}
+		if event.Metadata != nil {
+			for key, value := range *event.Metadata {
+				metadata[key] = value
+			}
+		}
+		event.Metadata = &metadata
+	}
+}
@@ -0,0 +1,78 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopeFromContext(t *testing.T) {
+	assert.Nil(t, ScopeFromContext(context.Background()))
+
+	ctx, scope := NewScope(context.Background(), Context{RequestID: "req-1"})
+	assert.Same(t, scope, ScopeFromContext(ctx))
+	assert.Equal(t, "req-1", scope.Context().RequestID)
+}
+
+func TestScope_Apply(t *testing.T) {
+	_, scope := NewScope(context.Background(), Context{IPAddress: "192.0.2.1"})
+	scope.SetTeamID("team-1")
+	scope.SetActor(Actor{ID: "user-1", Type: ActorTypeUser})
+	scope.SetTarget(Target{Type: "gateway", ID: "gw-1"})
+	scope.AddMetadata("region", "eu")
+	scope.AddMetadata("source", "scope")
+
+	event := Event{
+		Event:    EventInfo{Type: "test.event"},
+		Metadata: &Metadata{"source": "event"},
+	}
+	scope.Apply(&event)
+
+	assert.Equal(t, "team-1", event.TeamID)
+	assert.Equal(t, "user-1", event.Actor.ID)
+	assert.Equal(t, "gw-1", event.Target.ID)
+	assert.Equal(t, "192.0.2.1", event.Context.IPAddress)
+	assert.Equal(t, Metadata{"region": "eu", "source": "event"}, *event.Metadata)
+}
+
+func TestScope_ApplyKeepsEventFields(t *testing.T) {
+	_, scope := NewScope(context.Background(), Context{IPAddress: "192.0.2.1"})
+	scope.SetTeamID("team-1")
+	scope.SetActor(Actor{ID: "user-1"})
+
+	event := Event{
+		TeamID:  "team-2",
+		Actor:   &Actor{ID: "user-2"},
+		Context: &Context{IPAddress: "198.51.100.1"},
+	}
+	scope.Apply(&event)
+
+	assert.Equal(t, "team-2", event.TeamID)
+	assert.Equal(t, "user-2", event.Actor.ID)
+	assert.Equal(t, "198.51.100.1", event.Context.IPAddress)
+	assert.Nil(t, event.Metadata)
+}
+
+func TestClient_EmitContext_AppliesScope(t *testing.T) {
+	mock := &mockProducer{}
+	c := &client{
+		config:   &Config{},
+		producer: mock,
+		topics:   []string{"events"},
+		logger:   testLogger(),
+	}
+
+	ctx, scope := NewScope(context.Background(), Context{RequestID: "req-1"})
+	scope.SetTeamID("team-1")
+
+	require.NoError(t, c.EmitContext(ctx, Event{Event: EventInfo{Type: "test.event"}}))
+	require.Len(t, mock.producedMessages, 1)
+
+	var event Event
+	require.NoError(t, json.Unmarshal(mock.producedMessages[0].value, &event))
+	assert.Equal(t, "team-1", event.TeamID)
+	assert.Equal(t, "req-1", event.Context.RequestID)
+}
@@ -0,0 +1,157 @@
+package audit
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+const (
+	AlgorithmEdDSA = "EdDSA"
+	AlgorithmES256 = "ES256"
+)
+
+type Signer interface {
+	KeyID() string
+	Algorithm() string
+	Public() crypto.PublicKey
+	Sign(data []byte) ([]byte, error)
+}
+
+type ed25519Signer struct {
+	keyID string
+	key   ed25519.PrivateKey
+}
+
+func NewEd25519Signer(keyID string, key ed25519.PrivateKey) (Signer, error) {
+	if keyID == "" {
+		return nil, ErrEmptyKeyID
+	}
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("audit: invalid ed25519 private key size %d", len(key))
+	}
+	return &ed25519Signer{keyID: keyID, key: key}, nil
+}
+
+func (s *ed25519Signer) KeyID() string            { return s.keyID }
+func (s *ed25519Signer) Algorithm() string        { return AlgorithmEdDSA }
+func (s *ed25519Signer) Public() crypto.PublicKey { return s.key.Public() }
+
+func (s *ed25519Signer) Sign(data []byte) ([]byte, error) {
+	return ed25519.Sign(s.key, data), nil
+}
+
+type ecdsaSigner struct {
+	keyID string
+	key   *ecdsa.PrivateKey
+}
+
+func NewECDSASigner(keyID string, key *ecdsa.PrivateKey) (Signer, error) {
+	if keyID == "" {
+		return nil, ErrEmptyKeyID
+	}
+	if key == nil || key.Curve != elliptic.P256() {
+		return nil, errors.New("audit: ecdsa signer requires a P-256 private key")
+	}
+	return &ecdsaSigner{keyID: keyID, key: key}, nil
+}
+
+func (s *ecdsaSigner) KeyID() string            { return s.keyID }
+func (s *ecdsaSigner) Algorithm() string        { return AlgorithmES256 }
+func (s *ecdsaSigner) Public() crypto.PublicKey { return &s.key.PublicKey }
+
+// Sign produces the fixed-size r||s encoding used by JWS ES256 rather than
+// ASN.1, so signatures can be checked with standard JOSE libraries.
+func (s *ecdsaSigner) Sign(data []byte) ([]byte, error) {
+	digest := sha256.Sum256(data)
+	r, sig, err := ecdsa.Sign(rand.Reader, s.key, digest[:])
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 64)
+	r.FillBytes(out[:32])
+	sig.FillBytes(out[32:])
+	return out, nil
+}
+
+func LoadSigner(keyID, path string) (Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSigner(keyID, data)
+}
+
+func ParseSigner(keyID string, pemData []byte) (Signer, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, errors.New("audit: no PEM block found in signing key")
+	}
+
+	if block.Type == "EC PRIVATE KEY" {
+		key, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return NewECDSASigner(keyID, key)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		return NewEd25519Signer(keyID, k)
+	case *ecdsa.PrivateKey:
+		return NewECDSASigner(keyID, k)
+	default:
+		return nil, fmt.Errorf("audit: unsupported signing key type %T", key)
+	}
+}
+
+// SigningPayload returns the bytes covered by an event signature: the
+// canonical encoding of the event without its signature block.
+func SigningPayload(event Event) ([]byte, error) {
+	event.Signature = nil
+	return canonicalJSON(event)
+}
+
+func SignEvent(event *Event, signer Signer) error {
+	payload, err := SigningPayload(*event)
+	if err != nil {
+		return err
+	}
+
+	sig, err := signPayload(signer, payload)
+	if err != nil {
+		return err
+	}
+
+	event.Signature = sig
+	return nil
+}
+
+func signPayload(signer Signer, payload []byte) (*Signature, error) {
+	sig, err := signer.Sign(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Signature{
+		KeyID:     signer.KeyID(),
+		Algorithm: signer.Algorithm(),
+		Value:     base64.RawURLEncoding.EncodeToString(sig),
+	}, nil
+}
@@ -0,0 +1,130 @@
+package audit
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestEd25519Signer(t *testing.T, keyID string) Signer {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	signer, err := NewEd25519Signer(keyID, key)
+	require.NoError(t, err)
+	return signer
+}
+
+func TestNewEd25519Signer_Validation(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	_, err = NewEd25519Signer("", key)
+	assert.Equal(t, ErrEmptyKeyID, err)
+
+	_, err = NewEd25519Signer("kid", key[:10])
+	assert.Error(t, err)
+}
+
+func TestNewECDSASigner_RequiresP256(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	_, err = NewECDSASigner("kid", key)
+	assert.Error(t, err)
+}
+
+func TestSignEvent_Ed25519(t *testing.T) {
+	signer := newTestEd25519Signer(t, "key-1")
+
+	event := Event{ID: "evt-1", TeamID: "team-1", Event: EventInfo{Type: "test.event"}}
+	require.NoError(t, SignEvent(&event, signer))
+
+	require.NotNil(t, event.Signature)
+	assert.Equal(t, "key-1", event.Signature.KeyID)
+	assert.Equal(t, AlgorithmEdDSA, event.Signature.Algorithm)
+
+	payload, err := SigningPayload(event)
+	require.NoError(t, err)
+
+	sig, err := base64.RawURLEncoding.DecodeString(event.Signature.Value)
+	require.NoError(t, err)
+	assert.True(t, ed25519.Verify(signer.Public().(ed25519.PublicKey), payload, sig))
+}
+
+func TestSignEvent_ECDSA(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	signer, err := NewECDSASigner("key-ec", key)
+	require.NoError(t, err)
+
+	event := Event{ID: "evt-1", TeamID: "team-1", Event: EventInfo{Type: "test.event"}}
+	require.NoError(t, SignEvent(&event, signer))
+	assert.Equal(t, AlgorithmES256, event.Signature.Algorithm)
+
+	sig, err := base64.RawURLEncoding.DecodeString(event.Signature.Value)
+	require.NoError(t, err)
+	require.Len(t, sig, 64)
+
+	payload, err := SigningPayload(event)
+	require.NoError(t, err)
+	digest := sha256.Sum256(payload)
+
+	r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+	assert.True(t, ecdsa.Verify(&key.PublicKey, digest[:], r, s))
+}
+
+func TestParseSigner(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(edKey)
+	require.NoError(t, err)
+
+	signer, err := ParseSigner("ed", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}))
+	require.NoError(t, err)
+	assert.Equal(t, AlgorithmEdDSA, signer.Algorithm())
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalECPrivateKey(ecKey)
+	require.NoError(t, err)
+
+	signer, err = ParseSigner("ec", pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}))
+	require.NoError(t, err)
+	assert.Equal(t, AlgorithmES256, signer.Algorithm())
+	assert.Equal(t, "ec", signer.KeyID())
+
+	_, err = ParseSigner("bad", []byte("not a key"))
+	assert.Error(t, err)
+}
+
+func TestClient_Emit_SignsChainedEvent(t *testing.T) {
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{HashChain: &HashChainConfig{Enable: true, ProducerID: "producer-a"}})
+	c.signer = newTestEd25519Signer(t, "key-1")
+
+	emitN(t, c, "team-1", 2)
+
+	events := emittedEvents(t, mock)
+	require.Len(t, events, 2)
+
+	for _, event := range events {
+		require.NotNil(t, event.Signature)
+		assert.Equal(t, "key-1", event.Signature.KeyID)
+	}
+
+	report := VerifyChain(events)
+	assert.True(t, report.OK(), "%+v", report.Issues)
+}
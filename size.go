@@ -0,0 +1,320 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	DefaultMaxEventSize = 1000000
+
+	truncationMarker = "...[truncated]"
+	truncationKeep   = 64
+)
+
+var (
+	placeholderHash    = strings.Repeat("0", sha256.Size*2)
+	placeholderBatchID = "00000000-0000-0000-0000-000000000000"
+)
+
+type OversizePolicy string
+
+const (
+	OversizeReject      OversizePolicy = "reject"
+	OversizeTruncate    OversizePolicy = "truncate"
+	OversizeDropChanges OversizePolicy = "drop_changes"
+)
+
+type EventTooLargeError struct {
+	EventID string
+	Size    int
+	Limit   int
+}
+
+func (e *EventTooLargeError) Error() string {
+	return fmt.Sprintf("audit: event %s is %d bytes, exceeding the %d byte limit", e.EventID, e.Size, e.Limit)
+}
+
+func (e *EventTooLargeError) Is(target error) bool {
+	return target == ErrEventTooLarge
+}
+
+// enforceSizeLimit measures the event as it will be published, with room for
+// the integrity, batch and signature blocks that are only added afterwards,
+// so that the policies shrink it enough for those blocks to fit.
+func (c *client) enforceSizeLimit(event *Event) error {
+	limit := c.config.MaxEventSize
+	if limit <= 0 {
+		return nil
+	}
+
+	sealed := c.withSealBlocks(*event)
+	size, err := encodedSize(&sealed)
+	if err != nil {
+		c.handleError(*event, err, "failed to encode audit event")
+		c.observer().EncodeFailed(event, err)
+		return err
+	}
+	if size <= limit {
+		return nil
+	}
+
+	switch c.config.OversizePolicy {
+	case OversizeDropChanges:
+		err = dropChanges(&sealed, size, limit)
+	case OversizeTruncate:
+		err = truncateFields(&sealed, size, limit)
+	default:
+		return &EventTooLargeError{EventID: event.ID, Size: size, Limit: limit}
+	}
+
+	sealed.Integrity, sealed.Batch, sealed.Signature = event.Integrity, event.Batch, event.Signature
+	*event = sealed
+	return err
+}
+
+// withSealBlocks returns a copy of event carrying placeholders at least as
+// large as the blocks the hash chain, checkpointer and signer add.
+func (c *client) withSealBlocks(event Event) Event {
+	if c.chain != nil {
+		event.Integrity = &Integrity{
+			ChainID:    c.chain.chainID(event.TeamID),
+			ProducerID: c.chain.producerID,
+			Sequence:   math.MaxUint64,
+			PrevHash:   placeholderHash,
+			Hash:       placeholderHash,
+		}
+	}
+	if c.checkpoints != nil {
+		event.Batch = &BatchRef{ID: placeholderBatchID, Index: math.MaxInt}
+	}
+	if c.signer != nil {
+		event.Signature = &Signature{
+			KeyID:     c.signer.KeyID(),
+			Algorithm: c.signer.Algorithm(),
+			Value:     strings.Repeat("A", c.signatureLength()),
+		}
+	}
+	return event
+}
+
+// signatureLength returns the encoded length of the signer's signatures,
+// learned once by signing a probe payload.
+func (c *client) signatureLength() int {
+	c.signatureOnce.Do(func() {
+		if sig, err := signPayload(c.signer, []byte("size probe")); err == nil {
+			c.signatureSize = len(sig.Value)
+		}
+	})
+	return c.signatureSize
+}
+
+func dropChanges(event *Event, size, limit int) error {
+	if event.Changes == nil {
+		return &EventTooLargeError{EventID: event.ID, Size: size, Limit: limit}
+	}
+
+	data, err := canonicalJSON(event.Changes)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+
+	event.Changes = nil
+	event.Truncation = &Truncation{
+		OriginalSize:   size,
+		ChangesDropped: true,
+		ChangesDigest:  "sha256:" + hex.EncodeToString(sum[:]),
+	}
+
+	return checkSize(event, limit)
+}
+
+type stringField struct {
+	path string
+	get  func() string
+	set  func(string)
+}
+
+func truncateFields(event *Event, size, limit int) error {
+	if event.Metadata != nil {
+		metadata, err := normalizeMap(*event.Metadata)
+		if err != nil {
+			return err
+		}
+		event.Metadata = (*Metadata)(&metadata)
+	}
+	if event.Changes != nil {
+		previous, err := normalizeMap(event.Changes.Previous)
+		if err != nil {
+			return err
+		}
+		current, err := normalizeMap(event.Changes.Current)
+		if err != nil {
+			return err
+		}
+		event.Changes = &Changes{Previous: previous, Current: current}
+	}
+
+	fields := collectStringFields(event)
+	event.Truncation = &Truncation{OriginalSize: size}
+	truncated := make(map[string]bool)
+
+	for {
+		current, err := encodedSize(event)
+		if err != nil {
+			return err
+		}
+		if current <= limit {
+			break
+		}
+
+		sort.SliceStable(fields, func(i, j int) bool { return len(fields[i].get()) > len(fields[j].get()) })
+
+		if len(fields) == 0 || len(fields[0].get()) <= truncationKeep+len(truncationMarker) {
+			return &EventTooLargeError{EventID: event.ID, Size: size, Limit: limit}
+		}
+
+		field := fields[0]
+		value := field.get()
+		if truncated[field.path] {
+			value = strings.TrimSuffix(value, truncationMarker)
+		}
+		keep := len(value) - (current - limit) - len(truncationMarker)
+		if keep < truncationKeep {
+			keep = truncationKeep
+		}
+		for keep > 0 && !utf8.RuneStart(value[keep]) {
+			keep--
+		}
+		field.set(value[:keep] + truncationMarker)
+
+		if !truncated[field.path] {
+			truncated[field.path] = true
+			event.Truncation.Fields = append(event.Truncation.Fields, field.path)
+		}
+	}
+
+	sort.Strings(event.Truncation.Fields)
+	return nil
+}
+
+func collectStringFields(event *Event) []stringField {
+	fields := []stringField{
+		{
+			path: "event.description",
+			get:  func() string { return event.Event.Description },
+			set:  func(s string) { event.Event.Description = s },
+		},
+		{
+			path: "event.error_message",
+			get:  func() string { return event.Event.ErrorMessage },
+			set:  func(s string) { event.Event.ErrorMessage = s },
+		},
+	}
+
+	if event.Metadata != nil {
+		fields = appendMapFields(fields, "metadata", *event.Metadata)
+	}
+	if event.Changes != nil {
+		fields = appendMapFields(fields, "changes.previous", event.Changes.Previous)
+		fields = appendMapFields(fields, "changes.current", event.Changes.Current)
+	}
+
+	// Fields truncated before, for example by an earlier attempt whose event
+	// is now replayed from the dead-letter topic, are left alone.
+	candidates := fields[:0]
+	for _, field := range fields {
+		if !strings.HasSuffix(field.get(), truncationMarker) {
+			candidates = append(candidates, field)
+		}
+	}
+	return candidates
+}
+
+func appendMapFields(fields []stringField, prefix string, m map[string]interface{}) []stringField {
+	for key, value := range m {
+		path := prefix + "." + key
+		switch v := value.(type) {
+		case string:
+			fields = append(fields, stringField{
+				path: path,
+				get:  func() string { return m[key].(string) },
+				set:  func(s string) { m[key] = s },
+			})
+		case map[string]interface{}:
+			fields = appendMapFields(fields, path, v)
+		case []interface{}:
+			fields = appendSliceFields(fields, path, v)
+		}
+	}
+	return fields
+}
+
+func appendSliceFields(fields []stringField, prefix string, s []interface{}) []stringField {
+	for i, value := range s {
+		path := fmt.Sprintf("%s[%d]", prefix, i)
+		switch v := value.(type) {
+		case string:
+			fields = append(fields, stringField{
+				path: path,
+				get:  func() string { return s[i].(string) },
+				set:  func(str string) { s[i] = str },
+			})
+		case map[string]interface{}:
+			fields = appendMapFields(fields, path, v)
+		case []interface{}:
+			fields = appendSliceFields(fields, path, v)
+		}
+	}
+	return fields
+}
+
+// normalizeMap returns a deep copy of m holding only the types produced by
+// decoding JSON, so that typed maps, slices and structs can be walked too.
+// Numbers are kept as json.Number to encode exactly as before.
+func normalizeMap(m map[string]interface{}) (map[string]interface{}, error) {
+	if m == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var out map[string]interface{}
+	if err := decoder.Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func checkSize(event *Event, limit int) error {
+	size, err := encodedSize(event)
+	if err != nil {
+		return err
+	}
+	if size > limit {
+		return &EventTooLargeError{EventID: event.ID, Size: size, Limit: limit}
+	}
+	return nil
+}
+
+func encodedSize(event *Event) (int, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
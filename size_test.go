@@ -0,0 +1,216 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func oversizedEvent() Event {
+	return Event{
+		TeamID: "team-1",
+		Event: EventInfo{
+			Type:        "test.event",
+			Description: "short",
+		},
+		Metadata: &Metadata{
+			"payload": strings.Repeat("a", 4000),
+			"nested":  map[string]interface{}{"blob": strings.Repeat("b", 2000)},
+			"count":   3,
+		},
+		Changes: &Changes{
+			Previous: map[string]interface{}{"config": strings.Repeat("c", 1000)},
+			Current:  map[string]interface{}{"config": strings.Repeat("d", 1000)},
+		},
+	}
+}
+
+func TestEnforceSizeLimit_Reject(t *testing.T) {
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{MaxEventSize: 1024, OversizePolicy: OversizeReject})
+
+	err := c.Emit(oversizedEvent())
+
+	var tooLarge *EventTooLargeError
+	require.ErrorAs(t, err, &tooLarge)
+	assert.ErrorIs(t, err, ErrEventTooLarge)
+	assert.Equal(t, 1024, tooLarge.Limit)
+	assert.Greater(t, tooLarge.Size, 1024)
+	assert.Empty(t, mock.producedMessages)
+}
+
+func TestEnforceSizeLimit_UnderLimitUntouched(t *testing.T) {
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{MaxEventSize: DefaultMaxEventSize, OversizePolicy: OversizeTruncate})
+
+	require.NoError(t, c.Emit(oversizedEvent()))
+	require.Len(t, mock.producedMessages, 1)
+	assert.NotContains(t, string(mock.producedMessages[0].value), "truncation")
+}
+
+func TestEnforceSizeLimit_Truncate(t *testing.T) {
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{MaxEventSize: 3000, OversizePolicy: OversizeTruncate})
+
+	original := oversizedEvent()
+	require.NoError(t, c.Emit(original))
+	require.Len(t, mock.producedMessages, 1)
+
+	value := mock.producedMessages[0].value
+	assert.LessOrEqual(t, len(value), 3000)
+
+	var event Event
+	require.NoError(t, json.Unmarshal(value, &event))
+
+	require.NotNil(t, event.Truncation)
+	assert.Greater(t, event.Truncation.OriginalSize, 3000)
+	assert.Contains(t, event.Truncation.Fields, "metadata.payload")
+	assert.Contains(t, event.Truncation.Fields, "metadata.nested.blob")
+	assert.True(t, strings.HasSuffix((*event.Metadata)["payload"].(string), truncationMarker))
+	assert.Equal(t, "short", event.Event.Description)
+	assert.Equal(t, float64(3), (*event.Metadata)["count"])
+
+	assert.Len(t, (*original.Metadata)["payload"], 4000, "caller's metadata must not be modified")
+}
+
+func TestEnforceSizeLimit_TruncateTypedMetadata(t *testing.T) {
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{MaxEventSize: 1500, OversizePolicy: OversizeTruncate})
+
+	type request struct {
+		Body string `json:"body"`
+	}
+	require.NoError(t, c.Emit(Event{
+		TeamID: "team-1",
+		Event:  EventInfo{Type: "test.event"},
+		Metadata: &Metadata{
+			"headers": map[string]string{"cookie": strings.Repeat("a", 1000)},
+			"tags":    []string{strings.Repeat("b", 1000)},
+			"request": request{Body: strings.Repeat("c", 1000)},
+		},
+	}))
+	require.Len(t, mock.producedMessages, 1)
+
+	value := mock.producedMessages[0].value
+	assert.LessOrEqual(t, len(value), 1500)
+
+	var event Event
+	require.NoError(t, json.Unmarshal(value, &event))
+	assert.ElementsMatch(t, []string{"metadata.headers.cookie", "metadata.request.body", "metadata.tags[0]"}, event.Truncation.Fields)
+}
+
+func TestEnforceSizeLimit_TruncateKeepsSingleMarker(t *testing.T) {
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{MaxEventSize: 1200, OversizePolicy: OversizeTruncate})
+
+	replayed := strings.Repeat("a", 500) + truncationMarker
+	require.NoError(t, c.Emit(Event{
+		TeamID:   "team-1",
+		Event:    EventInfo{Type: "test.event"},
+		Metadata: &Metadata{"payload": replayed, "body": strings.Repeat("b", 1000)},
+	}))
+	require.Len(t, mock.producedMessages, 1)
+
+	var event Event
+	require.NoError(t, json.Unmarshal(mock.producedMessages[0].value, &event))
+	assert.Equal(t, replayed, (*event.Metadata)["payload"])
+	assert.Equal(t, []string{"metadata.body"}, event.Truncation.Fields)
+
+	body := (*event.Metadata)["body"].(string)
+	assert.Equal(t, 1, strings.Count(body, truncationMarker))
+}
+
+func TestEnforceSizeLimit_TruncateLeavesRoomForIntegrity(t *testing.T) {
+	for _, policy := range []OversizePolicy{OversizeTruncate, OversizeDropChanges} {
+		t.Run(string(policy), func(t *testing.T) {
+			mock := &mockProducer{}
+			c := newTestClient(mock, &Config{
+				MaxEventSize:   2000,
+				OversizePolicy: policy,
+				HashChain:      &HashChainConfig{Enable: true, ProducerID: "producer-a"},
+				Signer:         newTestEd25519Signer(t, "key-1"),
+				Checkpoint:     testCheckpointConfig(nil),
+			})
+
+			event := oversizedEvent()
+			(*event.Metadata)["payload"] = strings.Repeat("a", 200)
+			delete(*event.Metadata, "nested")
+			require.NoError(t, c.Emit(event))
+			require.Len(t, mock.producedMessages, 1)
+			assert.LessOrEqual(t, len(mock.producedMessages[0].value), 2000)
+
+			var published Event
+			require.NoError(t, json.Unmarshal(mock.producedMessages[0].value, &published))
+			require.NotNil(t, published.Integrity)
+			require.NotNil(t, published.Signature)
+			require.NotNil(t, published.Truncation)
+		})
+	}
+}
+
+func TestEnforceSizeLimit_TruncateCannotFit(t *testing.T) {
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{MaxEventSize: 200, OversizePolicy: OversizeTruncate})
+
+	event := Event{
+		TeamID:   "team-1",
+		Event:    EventInfo{Type: "test.event"},
+		Metadata: &Metadata{},
+	}
+	for i := 0; i < 50; i++ {
+		(*event.Metadata)[strings.Repeat("k", i+1)] = i
+	}
+
+	assert.ErrorIs(t, c.Emit(event), ErrEventTooLarge)
+	assert.Empty(t, mock.producedMessages)
+}
+
+func TestEnforceSizeLimit_DropChanges(t *testing.T) {
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{MaxEventSize: 7000, OversizePolicy: OversizeDropChanges})
+
+	original := oversizedEvent()
+	require.NoError(t, c.Emit(original))
+	require.Len(t, mock.producedMessages, 1)
+
+	var event Event
+	require.NoError(t, json.Unmarshal(mock.producedMessages[0].value, &event))
+
+	assert.Nil(t, event.Changes)
+	require.NotNil(t, event.Truncation)
+	assert.True(t, event.Truncation.ChangesDropped)
+
+	data, err := canonicalJSON(original.Changes)
+	require.NoError(t, err)
+	sum := sha256.Sum256(data)
+	assert.Equal(t, "sha256:"+hex.EncodeToString(sum[:]), event.Truncation.ChangesDigest)
+}
+
+func TestEnforceSizeLimit_DropChangesStillTooLarge(t *testing.T) {
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{MaxEventSize: 3000, OversizePolicy: OversizeDropChanges})
+
+	assert.ErrorIs(t, c.Emit(oversizedEvent()), ErrEventTooLarge)
+	assert.Empty(t, mock.producedMessages)
+}
+
+func TestTruncateFields_RespectsRuneBoundaries(t *testing.T) {
+	event := Event{
+		ID:       "evt-1",
+		TeamID:   "team-1",
+		Event:    EventInfo{Type: "test.event"},
+		Metadata: &Metadata{"text": strings.Repeat("é", 1000)},
+	}
+
+	require.NoError(t, truncateFields(&event, 2200, 600))
+
+	text := (*event.Metadata)["text"].(string)
+	assert.True(t, strings.HasSuffix(text, truncationMarker))
+	assert.True(t, strings.HasPrefix(text, "éé"))
+	assert.NotContains(t, text, "�")
+}
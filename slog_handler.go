@@ -0,0 +1,251 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+const (
+	DefaultSlogGroup  = "audit"
+	DefaultSlogMarker = "audit"
+)
+
+type SlogHandlerOptions struct {
+	Group   string
+	Marker  string
+	Level   slog.Leveler
+	OnError func(ctx context.Context, record slog.Record, err error)
+}
+
+// SlogHandler wraps another slog.Handler and turns log records tagged for
+// auditing into events emitted through a Client. A record is tagged when it
+// carries a group named Group, or a boolean attribute named Marker set to
+// true. Only top-level attributes and those in the Group map to event fields;
+// attributes in other groups, including groups opened with WithGroup, are
+// kept in the event metadata under the group. Every record is still passed
+// to the wrapped handler.
+type SlogHandler struct {
+	client Client
+	next   slog.Handler
+	opts   SlogHandlerOptions
+	attrs  []slog.Attr
+	groups []string
+}
+
+func NewSlogHandler(client Client, next slog.Handler, opts *SlogHandlerOptions) *SlogHandler {
+	h := &SlogHandler{client: client, next: next}
+	if opts != nil {
+		h.opts = *opts
+	}
+	if h.opts.Group == "" {
+		h.opts.Group = DefaultSlogGroup
+	}
+	if h.opts.Marker == "" {
+		h.opts.Marker = DefaultSlogMarker
+	}
+	if h.opts.Level == nil {
+		h.opts.Level = slog.LevelInfo
+	}
+	return h
+}
+
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.opts.Level.Level() || h.next.Enabled(ctx, level)
+}
+
+func (h *SlogHandler) Handle(ctx context.Context, record slog.Record) error {
+	var emitErr error
+	if record.Level >= h.opts.Level.Level() {
+		if event, ok := h.event(record); ok {
+			if e, ok := h.client.(ContextEmitter); ok {
+				emitErr = e.EmitContext(ctx, event)
+			} else {
+				emitErr = h.client.Emit(event)
+			}
+			if emitErr != nil && h.opts.OnError != nil {
+				h.opts.OnError(ctx, record, emitErr)
+			}
+		}
+	}
+
+	if !h.next.Enabled(ctx, record.Level) {
+		return emitErr
+	}
+	return errors.Join(emitErr, h.next.Handle(ctx, record))
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.next = h.next.WithAttrs(attrs)
+	clone.attrs = append(append([]slog.Attr(nil), h.attrs...), nestSlogAttrs(h.groups, attrs)...)
+	return &clone
+}
+
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	clone := *h
+	clone.next = h.next.WithGroup(name)
+	if name != "" {
+		clone.groups = append(append([]string(nil), h.groups...), name)
+	}
+	return &clone
+}
+
+// nestSlogAttrs places attrs inside the given groups, outermost first, the way
+// the wrapped handler sees them.
+func nestSlogAttrs(groups []string, attrs []slog.Attr) []slog.Attr {
+	if len(attrs) == 0 {
+		return nil
+	}
+	for i := len(groups) - 1; i >= 0; i-- {
+		attrs = []slog.Attr{{Key: groups[i], Value: slog.GroupValue(attrs...)}}
+	}
+	return attrs
+}
+
+func (h *SlogHandler) event(record slog.Record) (Event, bool) {
+	var fields []slog.Attr
+	tagged := false
+
+	collect := func(a slog.Attr) bool {
+		a.Value = a.Value.Resolve()
+		switch {
+		case a.Key == h.opts.Group && a.Value.Kind() == slog.KindGroup:
+			tagged = true
+			fields = append(fields, a.Value.Group()...)
+		case a.Key == h.opts.Marker && a.Value.Kind() == slog.KindBool:
+			tagged = tagged || a.Value.Bool()
+		default:
+			fields = append(fields, a)
+		}
+		return true
+	}
+
+	for _, a := range h.attrs {
+		collect(a)
+	}
+	attrs := make([]slog.Attr, 0, record.NumAttrs())
+	record.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	for _, a := range nestSlogAttrs(h.groups, attrs) {
+		collect(a)
+	}
+
+	if !tagged {
+		return Event{}, false
+	}
+
+	event := Event{
+		Event: EventInfo{
+			Type:        record.Message,
+			Description: record.Message,
+			Status:      "success",
+		},
+	}
+	if !record.Time.IsZero() {
+		event.Timestamp = Timestamp{Time: record.Time.UTC()}
+	}
+	if record.Level >= slog.LevelError {
+		event.Event.Status = "failure"
+		event.Event.ErrorMessage = record.Message
+	}
+
+	metadata := Metadata{}
+	for _, a := range fields {
+		applySlogAttr(&event, metadata, a)
+	}
+	if len(metadata) > 0 {
+		event.Metadata = &metadata
+	}
+
+	return event, true
+}
+
+func applySlogAttr(event *Event, metadata Metadata, a slog.Attr) {
+	value := a.Value.Resolve()
+
+	switch a.Key {
+	case "team_id":
+		event.TeamID = value.String()
+	case "type", "event_type":
+		event.Event.Type = value.String()
+	case "category":
+		event.Event.Category = value.String()
+	case "description":
+		event.Event.Description = value.String()
+	case "status":
+		event.Event.Status = value.String()
+	case "error_message":
+		event.Event.ErrorMessage = value.String()
+	case "actor":
+		if value.Kind() == slog.KindGroup {
+			for _, field := range value.Group() {
+				applySlogAttr(event, metadata, slog.Attr{Key: "actor_" + field.Key, Value: field.Value})
+			}
+			return
+		}
+		metadata[a.Key] = slogValue(value)
+	case "actor_id":
+		actor(event).ID = value.String()
+	case "actor_email":
+		actor(event).Email = value.String()
+	case "actor_type":
+		actor(event).Type = ActorType(value.String())
+	case "target":
+		if value.Kind() == slog.KindGroup {
+			for _, field := range value.Group() {
+				applySlogAttr(event, metadata, slog.Attr{Key: "target_" + field.Key, Value: field.Value})
+			}
+			return
+		}
+		metadata[a.Key] = slogValue(value)
+	case "target_id":
+		event.Target.ID = value.String()
+	case "target_type":
+		event.Target.Type = value.String()
+	case "target_name":
+		event.Target.Name = value.String()
+	default:
+		mergeMetadata(metadata, a.Key, slogValue(value))
+	}
+}
+
+// mergeMetadata sets key to value, merging groups that were added in several
+// parts, for example by successive With calls inside the same group.
+func mergeMetadata(metadata map[string]interface{}, key string, value interface{}) {
+	group, ok := value.(map[string]interface{})
+	existing, exists := metadata[key].(map[string]interface{})
+	if !ok || !exists {
+		metadata[key] = value
+		return
+	}
+	for k, v := range group {
+		mergeMetadata(existing, k, v)
+	}
+}
+
+func actor(event *Event) *Actor {
+	if event.Actor == nil {
+		event.Actor = &Actor{}
+	}
+	return event.Actor
+}
+
+func slogValue(v slog.Value) interface{} {
+	switch v.Kind() {
+	case slog.KindGroup:
+		group := make(map[string]interface{}, len(v.Group()))
+		for _, a := range v.Group() {
+			group[a.Key] = slogValue(a.Value.Resolve())
+		}
+		return group
+	case slog.KindTime:
+		return v.Time().UTC().Format(timestampFormat)
+	case slog.KindDuration:
+		return v.Duration().String()
+	default:
+		return v.Any()
+	}
+}
@@ -0,0 +1,133 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSlogTestLogger(opts *SlogHandlerOptions) (*slog.Logger, *mockProducer, *bytes.Buffer) {
+	mock := &mockProducer{}
+	c := &client{config: &Config{}, producer: mock, topics: []string{"events"}, logger: testLogger()}
+
+	var out bytes.Buffer
+	next := slog.NewJSONHandler(&out, &slog.HandlerOptions{Level: slog.LevelWarn})
+	return slog.New(NewSlogHandler(c, next, opts)), mock, &out
+}
+
+func producedEvents(t *testing.T, mock *mockProducer) []Event {
+	t.Helper()
+	events := make([]Event, 0, len(mock.producedMessages))
+	for _, msg := range mock.producedMessages {
+		var event Event
+		require.NoError(t, json.Unmarshal(msg.value, &event))
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestSlogHandler_AuditGroup(t *testing.T) {
+	logger, mock, out := newSlogTestLogger(nil)
+
+	logger.Info("Gateway created",
+		slog.Group("audit",
+			slog.String("team_id", "team-1"),
+			slog.String("type", "gateway.created"),
+			slog.String("category", "gateway"),
+			slog.Group("actor", slog.String("id", "user-1"), slog.String("type", "user")),
+			slog.Group("target", slog.String("type", "gateway"), slog.String("id", "gw-1")),
+		),
+		slog.String("region", "eu"),
+		slog.Int("replicas", 3),
+	)
+	logger.Info("not audited", slog.String("team_id", "team-1"))
+
+	events := producedEvents(t, mock)
+	require.Len(t, events, 1)
+
+	event := events[0]
+	assert.Equal(t, "team-1", event.TeamID)
+	assert.Equal(t, "gateway.created", event.Event.Type)
+	assert.Equal(t, "gateway", event.Event.Category)
+	assert.Equal(t, "Gateway created", event.Event.Description)
+	assert.Equal(t, "success", event.Event.Status)
+	assert.Equal(t, "user-1", event.Actor.ID)
+	assert.Equal(t, ActorTypeUser, event.Actor.Type)
+	assert.Equal(t, "gw-1", event.Target.ID)
+	assert.Equal(t, "eu", (*event.Metadata)["region"])
+	assert.EqualValues(t, 3, (*event.Metadata)["replicas"])
+
+	assert.Empty(t, out.String(), "info records are below the wrapped handler's level")
+}
+
+func TestSlogHandler_MarkerAndAttrs(t *testing.T) {
+	logger, mock, out := newSlogTestLogger(nil)
+
+	logger = logger.With(slog.String("team_id", "team-1"), slog.Bool("audit", true)).WithGroup("request")
+	logger.Error("gateway.deleted", slog.String("target_id", "gw-1"), slog.String("actor_id", "user-1"))
+
+	events := producedEvents(t, mock)
+	require.Len(t, events, 1)
+
+	event := events[0]
+	assert.Equal(t, "team-1", event.TeamID)
+	assert.Equal(t, "gateway.deleted", event.Event.Type)
+	assert.Equal(t, "failure", event.Event.Status)
+	assert.Equal(t, "gateway.deleted", event.Event.ErrorMessage)
+	assert.Empty(t, event.Target.ID, "grouped attributes are not event fields")
+	assert.Nil(t, event.Actor)
+	assert.Equal(t, map[string]interface{}{"target_id": "gw-1", "actor_id": "user-1"}, (*event.Metadata)["request"])
+
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &record))
+	assert.Equal(t, "team-1", record["team_id"])
+	assert.Equal(t, map[string]interface{}{"target_id": "gw-1", "actor_id": "user-1"}, record["request"])
+}
+
+func TestSlogHandler_Groups(t *testing.T) {
+	logger, mock, _ := newSlogTestLogger(nil)
+
+	logger.With(slog.Group("audit", slog.String("team_id", "team-1"))).
+		WithGroup("http").
+		With(slog.String("method", "POST")).
+		Info("gateway.created", slog.String("team_id", "spoofed"))
+	logger.WithGroup("audit").Info("gateway.deleted", slog.String("team_id", "team-2"))
+
+	events := producedEvents(t, mock)
+	require.Len(t, events, 2)
+
+	assert.Equal(t, "team-1", events[0].TeamID)
+	assert.Equal(t, map[string]interface{}{"method": "POST", "team_id": "spoofed"}, (*events[0].Metadata)["http"])
+
+	assert.Equal(t, "team-2", events[1].TeamID)
+}
+
+func TestSlogHandler_EmitErrors(t *testing.T) {
+	var errs []error
+	logger, mock, out := newSlogTestLogger(&SlogHandlerOptions{
+		OnError: func(_ context.Context, _ slog.Record, err error) { errs = append(errs, err) },
+	})
+
+	logger.Warn("missing team", slog.Group("audit", slog.String("type", "test.event")))
+
+	assert.Empty(t, mock.producedMessages)
+	require.Len(t, errs, 1)
+	assert.Equal(t, ErrEmptyTeamID, errs[0])
+	assert.Contains(t, out.String(), "missing team")
+}
+
+func TestSlogHandler_Level(t *testing.T) {
+	logger, mock, _ := newSlogTestLogger(&SlogHandlerOptions{Level: slog.LevelWarn})
+
+	logger.Info("ignored", slog.Group("audit", slog.String("team_id", "team-1")))
+	logger.Warn("recorded", slog.Group("audit", slog.String("team_id", "team-1")))
+
+	events := producedEvents(t, mock)
+	require.Len(t, events, 1)
+	assert.Equal(t, "recorded", events[0].Event.Type)
+}
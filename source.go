@@ -0,0 +1,34 @@
+package audit
+
+import (
+	"os"
+	"runtime"
+)
+
+const SDKVersion = "0.1.0"
+
+const (
+	EnvPodName      = "POD_NAME"
+	EnvPodNamespace = "POD_NAMESPACE"
+	EnvNodeName     = "NODE_NAME"
+)
+
+// newSource resolves the source block once at startup. Pod, namespace and
+// node come from the environment variables usually populated through the
+// Kubernetes downward API.
+func newSource(cfg *SourceConfig) *Source {
+	hostname, _ := os.Hostname()
+
+	return &Source{
+		Service:     cfg.ServiceName,
+		Version:     cfg.ServiceVersion,
+		Environment: cfg.Environment,
+		Hostname:    hostname,
+		Pod:         os.Getenv(EnvPodName),
+		Namespace:   os.Getenv(EnvPodNamespace),
+		Node:        os.Getenv(EnvNodeName),
+		PID:         os.Getpid(),
+		SDKVersion:  SDKVersion,
+		GoVersion:   runtime.Version(),
+	}
+}
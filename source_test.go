@@ -0,0 +1,74 @@
+package audit
+
+import (
+	"encoding/json"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSource(t *testing.T) {
+	t.Setenv(EnvPodName, "gateway-api-7d9f")
+	t.Setenv(EnvPodNamespace, "platform")
+	t.Setenv(EnvNodeName, "node-1")
+
+	source := newSource(&SourceConfig{ServiceName: "gateway-api", ServiceVersion: "1.4.2", Environment: "prod"})
+
+	hostname, _ := os.Hostname()
+	assert.Equal(t, Source{
+		Service:     "gateway-api",
+		Version:     "1.4.2",
+		Environment: "prod",
+		Hostname:    hostname,
+		Pod:         "gateway-api-7d9f",
+		Namespace:   "platform",
+		Node:        "node-1",
+		PID:         os.Getpid(),
+		SDKVersion:  SDKVersion,
+		GoVersion:   runtime.Version(),
+	}, *source)
+}
+
+func TestSourceConfig_SetDefaultsFromEnv(t *testing.T) {
+	t.Setenv(EnvServiceName, "env-service")
+	t.Setenv(EnvEnvironment, "staging")
+
+	cfg := &Config{Source: &SourceConfig{Enable: true, ServiceName: "configured"}}
+	cfg.setDefaults()
+
+	assert.Equal(t, "configured", cfg.Source.ServiceName)
+	assert.Equal(t, "staging", cfg.Source.Environment)
+}
+
+func TestClient_Emit_AddsSource(t *testing.T) {
+	mock := &mockProducer{}
+	c := &client{
+		config:   &Config{},
+		producer: mock,
+		topics:   []string{"events"},
+		logger:   testLogger(),
+		source:   newSource(&SourceConfig{ServiceName: "gateway-api"}),
+	}
+
+	require.NoError(t, c.Emit(Event{TeamID: "team-1", Event: EventInfo{Type: "test.event"}}))
+	require.NoError(t, c.Emit(Event{TeamID: "team-1", Event: EventInfo{Type: "test.event"}, Source: &Source{Service: "override"}}))
+
+	var first, second Event
+	require.NoError(t, json.Unmarshal(mock.producedMessages[0].value, &first))
+	require.NoError(t, json.Unmarshal(mock.producedMessages[1].value, &second))
+
+	assert.Equal(t, "gateway-api", first.Source.Service)
+	assert.Equal(t, SDKVersion, first.Source.SDKVersion)
+	assert.Equal(t, "override", second.Source.Service)
+}
+
+func TestClient_Emit_NoSourceByDefault(t *testing.T) {
+	mock := &mockProducer{}
+	c := &client{config: &Config{}, producer: mock, topics: []string{"events"}, logger: testLogger()}
+
+	require.NoError(t, c.Emit(Event{TeamID: "team-1", Event: EventInfo{Type: "test.event"}}))
+	assert.NotContains(t, string(mock.producedMessages[0].value), `"source"`)
+}
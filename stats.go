@@ -0,0 +1,26 @@
+package audit
+
+import "github.com/NeuralTrust/audit-sdk-go/kafka"
+
+type (
+	Stats          = kafka.Stats
+	BrokerStats    = kafka.BrokerStats
+	TopicStats     = kafka.TopicStats
+	PartitionStats = kafka.PartitionStats
+	WindowStats    = kafka.WindowStats
+)
+
+// StatsReporter is implemented by clients and producers that expose the
+// librdkafka statistics.
+type StatsReporter interface {
+	Stats() (Stats, bool)
+}
+
+var _ StatsReporter = (*client)(nil)
+
+func (c *client) Stats() (Stats, bool) {
+	if s, ok := c.producer.(StatsReporter); ok {
+		return s.Stats()
+	}
+	return Stats{}, false
+}
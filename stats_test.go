@@ -0,0 +1,32 @@
+package audit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type statsProducer struct {
+	mockProducer
+	stats Stats
+}
+
+func (p *statsProducer) Stats() (Stats, bool) {
+	return p.stats, p.stats.Name != ""
+}
+
+func TestClient_Stats(t *testing.T) {
+	c := &client{config: &Config{}, producer: &mockProducer{}, logger: testLogger()}
+	_, ok := c.Stats()
+	assert.False(t, ok)
+
+	producer := &statsProducer{stats: Stats{
+		Name:    "audit-sdk#producer-1",
+		Brokers: map[string]BrokerStats{"kafka-1:9092/1": {State: "UP", RTT: WindowStats{Avg: 4500}}},
+	}}
+	c = &client{config: &Config{}, producer: producer, logger: testLogger()}
+
+	stats, ok := c.Stats()
+	assert.True(t, ok)
+	assert.Equal(t, int64(4500), stats.Brokers["kafka-1:9092/1"].RTT.Avg)
+}
@@ -0,0 +1,47 @@
+package audit
+
+import "fmt"
+
+const DefaultMaxTargets = 100
+
+type TargetOverflowPolicy string
+
+const (
+	TargetOverflowSummarize TargetOverflowPolicy = "summarize"
+	TargetOverflowReject    TargetOverflowPolicy = "reject"
+)
+
+func (t Target) IsZero() bool {
+	return t.Type == "" && t.ID == "" && t.Name == "" && t.Role == "" && t.Ancestors == nil
+}
+
+// normalizeTargets keeps Target populated for consumers that predate
+// Targets, and applies MaxTargets to bulk events.
+func (c *client) normalizeTargets(event *Event) error {
+	if event.Target.IsZero() && len(event.Targets) > 0 {
+		event.Target = event.Targets[0]
+	}
+
+	limit := c.config.MaxTargets
+	total := len(event.Targets)
+	if limit <= 0 || total <= limit {
+		return nil
+	}
+
+	if c.config.TargetOverflow == TargetOverflowReject {
+		return fmt.Errorf("%w: %d targets exceed the limit of %d", ErrTooManyTargets, total, limit)
+	}
+
+	summary := &TargetSummary{
+		Total:   total,
+		Omitted: total - limit,
+		ByType:  make(map[string]int),
+	}
+	for _, target := range event.Targets {
+		summary.ByType[target.Type]++
+	}
+
+	event.Targets = event.Targets[:limit:limit]
+	event.TargetSummary = summary
+	return nil
+}
@@ -0,0 +1,98 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func apiKeys(n int) []Target {
+	targets := make([]Target, n)
+	for i := range targets {
+		targets[i] = Target{Type: "api_key", ID: fmt.Sprintf("key-%d", i)}
+	}
+	return targets
+}
+
+func TestClient_Emit_MultipleTargets(t *testing.T) {
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{MaxTargets: DefaultMaxTargets, TargetOverflow: TargetOverflowSummarize})
+
+	project := TargetPath{{Type: "org", ID: "org-1"}, {Type: "project", ID: "proj-1"}}
+	org := project[:1]
+	require.NoError(t, c.Emit(Event{
+		TeamID: "team-1",
+		Event:  EventInfo{Type: "gateway.moved"},
+		Targets: []Target{
+			{Type: "gateway", ID: "gw-1", Ancestors: &project},
+			{Type: "project", ID: "proj-1", Role: "source", Ancestors: &org},
+			{Type: "project", ID: "proj-2", Role: "destination", Ancestors: &org},
+		},
+	}))
+
+	var event Event
+	require.NoError(t, json.Unmarshal(mock.producedMessages[0].value, &event))
+
+	assert.Equal(t, "gw-1", event.Target.ID, "Target mirrors the first of Targets")
+	require.Len(t, event.Targets, 3)
+	assert.Equal(t, "destination", event.Targets[2].Role)
+	assert.Equal(t, project, *event.Targets[0].Ancestors)
+	assert.Nil(t, event.TargetSummary)
+}
+
+func TestClient_Emit_SingleTargetUnchanged(t *testing.T) {
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{MaxTargets: DefaultMaxTargets, TargetOverflow: TargetOverflowSummarize})
+
+	require.NoError(t, c.Emit(Event{TeamID: "team-1", Event: EventInfo{Type: "test.event"}, Target: Target{Type: "gateway", ID: "gw-1"}}))
+
+	value := string(mock.producedMessages[0].value)
+	assert.Contains(t, value, `"target":{"type":"gateway","id":"gw-1"}`)
+	assert.NotContains(t, value, `"targets"`)
+}
+
+func TestClient_Emit_SummarizesTargets(t *testing.T) {
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{MaxTargets: 10, TargetOverflow: TargetOverflowSummarize})
+
+	targets := append(apiKeys(48), Target{Type: "gateway", ID: "gw-1"}, Target{Type: "gateway", ID: "gw-2"})
+	require.NoError(t, c.Emit(Event{TeamID: "team-1", Event: EventInfo{Type: "api_key.deleted"}, Targets: targets}))
+
+	var event Event
+	require.NoError(t, json.Unmarshal(mock.producedMessages[0].value, &event))
+
+	assert.Len(t, event.Targets, 10)
+	assert.Equal(t, &TargetSummary{Total: 50, Omitted: 40, ByType: map[string]int{"api_key": 48, "gateway": 2}}, event.TargetSummary)
+	assert.Len(t, targets, 50, "caller's slice must not be modified")
+}
+
+func TestClient_Emit_RejectsTooManyTargets(t *testing.T) {
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{MaxTargets: 10, TargetOverflow: TargetOverflowReject})
+
+	err := c.Emit(Event{TeamID: "team-1", Event: EventInfo{Type: "api_key.deleted"}, Targets: apiKeys(11)})
+	assert.ErrorIs(t, err, ErrTooManyTargets)
+	assert.Empty(t, mock.producedMessages)
+
+	c = newTestClient(mock, &Config{MaxTargets: -1, TargetOverflow: TargetOverflowReject})
+	assert.NoError(t, c.Emit(Event{TeamID: "team-1", Event: EventInfo{Type: "api_key.deleted"}, Targets: apiKeys(500)}))
+}
+
+func TestConfig_TargetDefaults(t *testing.T) {
+	cfg := &Config{}
+	cfg.setDefaults()
+
+	assert.Equal(t, DefaultMaxTargets, cfg.MaxTargets)
+	assert.Equal(t, TargetOverflowSummarize, cfg.TargetOverflow)
+}
+
+func TestTarget_Comparable(t *testing.T) {
+	path := TargetPath{{Type: "org", ID: "org-1"}}
+	seen := map[Target]bool{{Type: "gateway", ID: "gw-1", Ancestors: &path}: true}
+
+	assert.True(t, seen[Target{Type: "gateway", ID: "gw-1", Ancestors: &path}])
+	assert.True(t, Target{Type: "gateway", ID: "gw-1"} == Target{Type: "gateway", ID: "gw-1"})
+}
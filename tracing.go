@@ -0,0 +1,198 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/NeuralTrust/audit-sdk-go/kafka"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var errClientClosed = errors.New("audit: client closed before delivery")
+
+const tracerName = "github.com/NeuralTrust/audit-sdk-go"
+
+func newTracer(cfg *TracingConfig) trace.Tracer {
+	provider := cfg.TracerProvider
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return provider.Tracer(tracerName, trace.WithInstrumentationVersion(Version))
+}
+
+func captureTrace(ctx context.Context, event *Event) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+
+	if event.Context == nil {
+		event.Context = &Context{}
+	} else {
+		auditCtx := *event.Context
+		event.Context = &auditCtx
+	}
+
+	if event.Context.TraceID == "" {
+		event.Context.TraceID = sc.TraceID().String()
+		event.Context.SpanID = sc.SpanID().String()
+	}
+}
+
+func (c *client) startSpan(ctx context.Context, event *Event) (context.Context, trace.Span) {
+	return c.tracer.Start(ctx, "audit.Emit",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("audit.team_id", event.TeamID),
+			attribute.String("audit.event_type", event.Event.Type),
+			attribute.String("audit.category", event.Event.Category),
+		),
+	)
+}
+
+func (c *client) endSpan(span trace.Span, event *Event, err error) {
+	span.SetAttributes(attribute.String("audit.event_id", event.ID))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// addSpanEvent records the emitted audit event on the caller's span, so the
+// trace shows which audit records a request produced.
+func addSpanEvent(ctx context.Context, event *Event) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	span.AddEvent("audit.event", trace.WithAttributes(
+		attribute.String("audit.event_id", event.ID),
+		attribute.String("audit.event_type", event.Event.Type),
+		attribute.String("audit.category", event.Event.Category),
+		attribute.String("audit.team_id", event.TeamID),
+	))
+}
+
+// opaqueProducer is implemented by producers whose delivery and drop reports
+// carry a value given at produce time. The produce span of an event is only
+// started when the producer can hand it back this way.
+type opaqueProducer interface {
+	ProduceAsyncOpaque(topics []string, key, value []byte, opaque interface{})
+}
+
+var _ opaqueProducer = (*kafka.Producer)(nil)
+
+// produceSpans holds the audit.Produce span of each event in flight until
+// every topic has reported the outcome of its delivery. The span itself is
+// the opaque of the event's messages.
+type produceSpans struct {
+	mu    sync.Mutex
+	spans map[*produceSpan]struct{}
+}
+
+type produceSpan struct {
+	span    trace.Span
+	pending int
+	err     error
+}
+
+func newProduceSpans() *produceSpans {
+	return &produceSpans{spans: make(map[*produceSpan]struct{})}
+}
+
+// produceTraced produces the event, starting the span that ends once it has
+// been delivered to, or has failed on, every topic. The span is registered
+// before the event is produced, since a report can arrive before
+// ProduceAsyncOpaque returns.
+func (c *client) produceTraced(ctx context.Context, event *Event, topics []string, data []byte) {
+	p, ok := c.producer.(opaqueProducer)
+	if !ok || c.tracer == nil || c.spans == nil {
+		c.producer.ProduceAsync(topics, []byte(event.TeamID), data)
+		return
+	}
+
+	_, span := c.tracer.Start(ctx, "audit.Produce",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("audit.event_id", event.ID),
+			attribute.StringSlice("messaging.destination.names", topics),
+		),
+	)
+	s := &produceSpan{span: span, pending: len(topics)}
+
+	c.spans.mu.Lock()
+	c.spans.spans[s] = struct{}{}
+	c.spans.mu.Unlock()
+
+	p.ProduceAsyncOpaque(topics, []byte(event.TeamID), data, s)
+}
+
+// traceDelivery records the outcome for one topic on the produce span passed
+// as the message's opaque, ending the span after the last topic.
+func (c *client) traceDelivery(opaque interface{}, topic string, latency time.Duration, err error) {
+	s, ok := opaque.(*produceSpan)
+	if !ok || c.spans == nil {
+		return
+	}
+
+	c.spans.mu.Lock()
+	if _, ok := c.spans.spans[s]; !ok {
+		c.spans.mu.Unlock()
+		return
+	}
+	s.pending--
+	if err != nil && s.err == nil {
+		s.err = err
+	}
+	done := s.pending <= 0
+	if done {
+		delete(c.spans.spans, s)
+	}
+	c.spans.mu.Unlock()
+
+	attrs := []attribute.KeyValue{
+		attribute.String("messaging.destination.name", topic),
+		attribute.Int64("audit.latency_ms", latency.Milliseconds()),
+	}
+	if err != nil {
+		attrs = append(attrs, attribute.String("error", err.Error()))
+	}
+	s.span.AddEvent("audit.delivery", trace.WithAttributes(attrs...))
+
+	if done {
+		endProduceSpan(s)
+	}
+}
+
+// closeSpans ends the spans of events whose delivery was never reported.
+func (c *client) closeSpans() {
+	if c.spans == nil {
+		return
+	}
+
+	c.spans.mu.Lock()
+	spans := c.spans.spans
+	c.spans.spans = make(map[*produceSpan]struct{})
+	c.spans.mu.Unlock()
+
+	for s := range spans {
+		if s.err == nil {
+			s.err = errClientClosed
+		}
+		endProduceSpan(s)
+	}
+}
+
+func endProduceSpan(s *produceSpan) {
+	if s.err != nil {
+		s.span.RecordError(s.err)
+		s.span.SetStatus(codes.Error, s.err.Error())
+	}
+	s.span.End()
+}
@@ -0,0 +1,190 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/NeuralTrust/audit-sdk-go/kafka"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// opaqueMockProducer records the opaque of each event, for tests to pass
+// back in its reports.
+type opaqueMockProducer struct {
+	mockProducer
+	opaques []interface{}
+}
+
+func (m *opaqueMockProducer) ProduceAsyncOpaque(topics []string, key, value []byte, opaque interface{}) {
+	m.ProduceAsync(topics, key, value)
+	m.opaques = append(m.opaques, opaque)
+}
+
+func spanAttr(span sdktrace.ReadOnlySpan, key string) string {
+	for _, kv := range span.Attributes() {
+		if kv.Key == attribute.Key(key) {
+			return kv.Value.AsString()
+		}
+	}
+	return ""
+}
+
+func TestCaptureTrace(t *testing.T) {
+	provider := sdktrace.NewTracerProvider()
+	ctx, span := provider.Tracer("test").Start(context.Background(), "request")
+	defer span.End()
+
+	original := &Context{RequestID: "req-1"}
+	event := Event{Context: original}
+	captureTrace(ctx, &event)
+
+	assert.Equal(t, span.SpanContext().TraceID().String(), event.Context.TraceID)
+	assert.Equal(t, span.SpanContext().SpanID().String(), event.Context.SpanID)
+	assert.Equal(t, "req-1", event.Context.RequestID)
+	assert.Empty(t, original.TraceID, "caller's context must not be modified")
+
+	event = Event{}
+	captureTrace(context.Background(), &event)
+	assert.Nil(t, event.Context)
+}
+
+func TestClient_EmitContext_CapturesTraceWithoutTracing(t *testing.T) {
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{})
+
+	provider := sdktrace.NewTracerProvider()
+	ctx, span := provider.Tracer("test").Start(context.Background(), "request")
+	defer span.End()
+
+	require.NoError(t, c.EmitContext(ctx, Event{TeamID: "team-1", Event: EventInfo{Type: "test.event"}}))
+
+	var event Event
+	require.NoError(t, json.Unmarshal(mock.producedMessages[0].value, &event))
+	assert.Equal(t, span.SpanContext().TraceID().String(), event.Context.TraceID)
+}
+
+func TestClient_EmitContext_Spans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{Tracing: &TracingConfig{Enable: true, TracerProvider: provider, SpanEvents: true}})
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "request")
+	require.NoError(t, c.EmitContext(ctx, Event{
+		TeamID: "team-1",
+		Event:  EventInfo{Type: "gateway.created", Category: "gateway"},
+	}))
+	parent.End()
+
+	var event Event
+	require.NoError(t, json.Unmarshal(mock.producedMessages[0].value, &event))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	emitSpan, requestSpan := spans[0], spans[1]
+	assert.Equal(t, "audit.Emit", emitSpan.Name())
+	assert.Equal(t, trace.SpanKindProducer, emitSpan.SpanKind())
+	assert.Equal(t, parent.SpanContext().SpanID(), emitSpan.Parent().SpanID())
+	assert.Equal(t, event.ID, spanAttr(emitSpan, "audit.event_id"))
+	assert.Equal(t, "gateway.created", spanAttr(emitSpan, "audit.event_type"))
+
+	assert.Equal(t, parent.SpanContext().SpanID().String(), event.Context.SpanID)
+
+	require.Len(t, requestSpan.Events(), 1)
+	assert.Equal(t, "audit.event", requestSpan.Events()[0].Name)
+}
+
+func TestClient_EmitContext_SpanRecordsError(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	mock := &mockProducer{}
+	c := newTestClient(mock, &Config{Tracing: &TracingConfig{Enable: true, TracerProvider: provider, SpanEvents: true}})
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "request")
+	err := c.EmitContext(ctx, Event{Event: EventInfo{Type: "test.event"}})
+	parent.End()
+
+	assert.Equal(t, ErrEmptyTeamID, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Empty(t, spans[1].Events())
+}
+
+func TestClient_ProduceSpanEndsOnDelivery(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	mock := &opaqueMockProducer{}
+	c := newTestClient(mock, &Config{Tracing: &TracingConfig{Enable: true, TracerProvider: provider}}, "events", "logs")
+
+	require.NoError(t, c.EmitContext(context.Background(), Event{TeamID: "team-1", Event: EventInfo{Type: "test.event"}}))
+	require.Len(t, recorder.Ended(), 1, "the produce span waits for delivery")
+
+	value, opaque := mock.producedMessages[0].value, mock.opaques[0]
+	c.handleDelivery(kafka.DeliveryReport{Topic: "events", Value: value, Enqueued: true, Latency: 5 * time.Millisecond, Opaque: opaque})
+	require.Len(t, recorder.Ended(), 1)
+	c.handleDelivery(kafka.DeliveryReport{Topic: "logs", Value: value, Enqueued: true, Err: errors.New("Local: Message timed out"), Opaque: opaque})
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	emitSpan, produceSpan := spans[0], spans[1]
+	assert.Equal(t, "audit.Produce", produceSpan.Name())
+	assert.Equal(t, emitSpan.SpanContext().SpanID(), produceSpan.Parent().SpanID())
+	assert.Equal(t, codes.Error, produceSpan.Status().Code)
+	require.Len(t, produceSpan.Events(), 3)
+	assert.Equal(t, "audit.delivery", produceSpan.Events()[0].Name)
+	assert.Equal(t, "audit.delivery", produceSpan.Events()[1].Name)
+}
+
+func TestClient_Close_EndsProduceSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	c := newTestClient(&opaqueMockProducer{}, &Config{Tracing: &TracingConfig{Enable: true, TracerProvider: provider}})
+	require.NoError(t, c.EmitContext(context.Background(), Event{TeamID: "team-1", Event: EventInfo{Type: "test.event"}}))
+	require.NoError(t, c.Close())
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "audit.Produce", spans[1].Name())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+}
+
+func TestClient_ProduceSpansWithSameEventID(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	mock := &opaqueMockProducer{}
+	c := newTestClient(mock, &Config{Tracing: &TracingConfig{Enable: true, TracerProvider: provider}})
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, c.EmitContext(context.Background(), Event{ID: "evt-1", TeamID: "team-1", Event: EventInfo{Type: "test.event"}}))
+	}
+	for _, opaque := range mock.opaques {
+		c.handleDelivery(kafka.DeliveryReport{Topic: "events", Enqueued: true, Opaque: opaque})
+	}
+
+	var produced int
+	for _, span := range recorder.Ended() {
+		if span.Name() == "audit.Produce" {
+			produced++
+			assert.NotEqual(t, codes.Error, span.Status().Code)
+		}
+	}
+	assert.Equal(t, 2, produced)
+}